@@ -2,18 +2,28 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
 	"github.com/debugg-er/lox/src/interpreter"
 	"github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/optimizer"
 	"github.com/debugg-er/lox/src/parser"
+	"github.com/debugg-er/lox/src/tester"
 )
 
+var coverageFile = flag.String("coverage", "", "write an LCOV coverage report of the executed file, or of the test files with `lox test`, to `path`")
+
 func main() {
+	flag.Parse()
+	if flag.Arg(0) == "test" {
+		RunTests()
+	}
 	start := time.Now()
-	if len(os.Args) > 1 {
+	if flag.NArg() > 0 {
 		ExecFile()
 	} else {
 		EnterPrompt()
@@ -22,12 +32,60 @@ func main() {
 }
 
 func ExecFile() {
-	source, err := os.ReadFile(os.Args[1])
+	path := flag.Arg(0)
+	source, err := os.ReadFile(path)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "File not found")
 		os.Exit(1)
 	}
-	execute(string(source))
+	statements := parse(string(source))
+	if statements == nil {
+		return
+	}
+
+	// Optimizations remove dead code, which coverage has to report
+	var coverage *interpreter.Coverage
+	if *coverageFile != "" {
+		coverage = interpreter.NewCoverage(statements)
+		defer writeCoverage(coverage, path)
+	} else {
+		statements = optimizer.Optimize(statements)
+	}
+	interpreter := interpreter.NewInterpreter()
+	interpreter.SetCoverage(coverage)
+	if err := interpreter.Run(statements); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+	}
+}
+
+// RunTests runs `lox test [paths...]` and exits with a non-zero status
+// when a test fails
+func RunTests() {
+	paths := flag.Args()[1:]
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := tester.Discover(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	var coverage io.WriteCloser
+	if *coverageFile != "" {
+		if coverage, err = os.Create(*coverageFile); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
+	passed := tester.Run(files, os.Stdout, coverage)
+	// os.Exit skips deferred calls
+	if coverage != nil {
+		coverage.Close()
+	}
+	if !passed {
+		os.Exit(1)
+	}
+	os.Exit(0)
 }
 
 func EnterPrompt() {
@@ -40,6 +98,22 @@ func EnterPrompt() {
 }
 
 func execute(source string) {
+	statements := parse(source)
+	if statements == nil {
+		return
+	}
+	statements = optimizer.Optimize(statements)
+
+	interpreter := interpreter.NewInterpreter()
+	if err := interpreter.Run(statements); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+}
+
+// parse returns nil when the source contains errors, the errors are
+// reported to stderr
+func parse(source string) []parser.Stmt {
 	tokens, err := lexer.NewLexer().Parse(source)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
@@ -52,12 +126,21 @@ func execute(source string) {
 		for _, err := range errs {
 			fmt.Fprintln(os.Stderr, err.Error())
 		}
-		return
+		return nil
 	}
+	return statements
+}
 
-	interpreter := interpreter.NewInterpreter()
-	if err := interpreter.Run(statements); err != nil {
+func writeCoverage(coverage *interpreter.Coverage, source string) {
+	file, err := os.Create(*coverageFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return
+	}
+	defer file.Close()
+	if err := coverage.WriteLCOV(file, source); err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		return
 	}
+	fmt.Print(coverage.Summary(source))
 }
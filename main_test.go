@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The -coverage flag writes the LCOV report of the executed file and
+// prints its summary after the output of the program
+func TestCoverageFlag(t *testing.T) {
+	dir := t.TempDir()
+	program := filepath.Join(dir, "program.lox")
+	report := filepath.Join(dir, "coverage.info")
+	source := "var x = 1;\nif (x > 1) {\n  print \"big\";\n}\nprint x;\n"
+	if err := os.WriteFile(program, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := flag.CommandLine.Parse([]string{"-coverage", report, program}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { *coverageFile = "" }()
+
+	output := captureStdout(t, ExecFile)
+	expected := "1\n" +
+		"Coverage of " + program + "\n" +
+		"  Lines:    3/4 (75.0%)\n" +
+		"  Branches: 1/2 (50.0%)\n" +
+		"  Uncovered lines: 3\n" +
+		"  Partial branches: 2 (never true)\n"
+	if output != expected {
+		t.Errorf("expected output\n%s\ngot\n%s", expected, output)
+	}
+
+	lcov, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"SF:" + program, "BRDA:2,0,0,0", "BRDA:2,0,1,1", "DA:3,0", "LF:4", "LH:3"} {
+		if !strings.Contains(string(lcov), line+"\n") {
+			t.Errorf("expected %q in report\n%s", line, lcov)
+		}
+	}
+}
+
+// `lox test` exits with a non-zero status when a test fails. RunTests
+// exits the process, so it runs in a child process of the test binary.
+func TestRunTestsExitStatus(t *testing.T) {
+	if path := os.Getenv("LOX_TEST_PATH"); path != "" {
+		if err := flag.CommandLine.Parse([]string{"test", path}); err != nil {
+			t.Fatal(err)
+		}
+		RunTests()
+	}
+	for path, status := range map[string]int{
+		filepath.Join("src", "tester", "testdata"):                               1,
+		filepath.Join("src", "tester", "testdata", "nested", "passing_test.lox"): 0,
+	} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestRunTestsExitStatus$")
+		cmd.Env = append(os.Environ(), "LOX_TEST_PATH="+path)
+		output, err := cmd.Output()
+		code := 0
+		if exit, ok := err.(*exec.ExitError); ok {
+			code = exit.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if code != status {
+			t.Errorf("expected status %d for %s, got %d\n%s", status, path, code, output)
+		}
+		if !strings.Contains(string(output), " failed, ") {
+			t.Errorf("expected a summary for %s\n%s", path, output)
+		}
+	}
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+	f()
+	writer.Close()
+	return <-output
+}
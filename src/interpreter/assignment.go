@@ -0,0 +1,111 @@
+package interpreter
+
+import (
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+// compoundOperators maps a compound assignment to its binary operator
+var compoundOperators = map[l.TokenType]l.TokenType{
+	l.PLUS_EQUAL:    l.PLUS,
+	l.MINUS_EQUAL:   l.MINUS,
+	l.STAR_EQUAL:    l.STAR,
+	l.SLASH_EQUAL:   l.SLASH,
+	l.PERCENT_EQUAL: l.PERCENT,
+}
+
+// reference is an assignable location. The object and the index of its
+// target are evaluated once, when the reference is created.
+type reference struct {
+	get func() (*Value, error)
+	set func(value *Value) error
+}
+
+func (i *Interpreter) reference(target parser.Expr) (*reference, error) {
+	switch target := target.(type) {
+	case *parser.VariableExpr:
+		return &reference{
+			get: func() (*Value, error) { return i.env.get(target.Name) },
+			set: func(value *Value) error { return i.env.assign(target.Name, value) },
+		}, nil
+	case *parser.IndexExpr:
+		object, err := i.Evaluate(target.Object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := i.Evaluate(target.Index)
+		if err != nil {
+			return nil, err
+		}
+		return &reference{
+			get: func() (*Value, error) { return indexValue(object, index, target.Bracket) },
+			set: func(value *Value) error { return setIndex(object, index, value, target.Bracket) },
+		}, nil
+	case *parser.GetExpr:
+		object, err := i.Evaluate(target.Object)
+		if err != nil {
+			return nil, err
+		}
+		return &reference{
+			get: func() (*Value, error) { return property(object, target.Name) },
+			set: func(value *Value) error { return setProperty(object, target.Name, value) },
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// assign evaluates `target operator value`, operator being `=` or a
+// compound assignment
+func (i *Interpreter) assign(target parser.Expr, operator *l.Token, value parser.Expr) (*Value, error) {
+	ref, err := i.reference(target)
+	if err != nil {
+		return nil, err
+	}
+	var current *Value
+	if operator.Type != l.EQUAL {
+		if current, err = ref.get(); err != nil {
+			return nil, err
+		}
+	}
+	result, err := i.Evaluate(value)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		result, err = binaryOperation(compoundOperators[operator.Type], operator, current, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := ref.set(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func setIndex(object *Value, index *Value, value *Value, bracket *l.Token) error {
+	switch object := object.Data.(type) {
+	case *List:
+		position, err := toIndex(index, object.Len(), bracket)
+		if err != nil {
+			return err
+		}
+		object.Set(position, value)
+		return nil
+	case *Map:
+		object.Set(index, value)
+		return nil
+	default:
+		return NewRuntimeError(bracket, "Only list elements and map entries can be assigned.")
+	}
+}
+
+func setProperty(object *Value, name *l.Token, value *Value) error {
+	m, ok := object.Data.(*Map)
+	if !ok {
+		return NewRuntimeError(name, "Only map properties can be assigned.")
+	}
+	m.Set(NewValue(name.Value.(string)), value)
+	return nil
+}
@@ -0,0 +1,273 @@
+package interpreter
+
+import (
+	"errors"
+
+	l "github.com/debugg-er/lox/src/lexer"
+)
+
+var errClosedChannel = errors.New("Send on closed channel.")
+
+// Channel passes values between tasks, a send blocks until the value is
+// received unless the channel has a free slot in its buffer. Its state is
+// guarded by the scheduler lock.
+type Channel struct {
+	capacity  int
+	buffer    []*Value
+	closed    bool
+	senders   []operation // Blocked sends, in arrival order
+	receivers []operation
+}
+
+// operation is a send or receive a blocked task waits on, index is the
+// select case it belongs to
+type operation struct {
+	waiter *waiter
+	index  int
+	value  *Value
+}
+
+// WaitGroup waits for a collection of tasks to finish, like Go's
+// sync.WaitGroup but returning errors instead of panicking
+type WaitGroup struct {
+	count   int
+	waiters []*waiter // Tasks blocked until count drops to zero
+}
+
+// channel(capacity?) creates a channel, unbuffered by default
+func nativeChannel(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error) {
+	if len(arguments) > 1 {
+		return nil, NewRuntimeError(token, "channel expects an optional capacity.")
+	}
+	capacity := 0
+	if len(arguments) == 1 {
+		var err error
+		if capacity, err = toInteger(arguments[0], token); err != nil {
+			return nil, err
+		}
+		if capacity < 0 {
+			return nil, NewRuntimeError(token, "Channel capacity can't be negative.")
+		}
+	}
+	return NewValue(&Channel{capacity: capacity}), nil
+}
+
+func nativeWaitGroup(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error) {
+	return NewValue(&WaitGroup{}), nil
+}
+
+type channelMethod struct {
+	arity int
+	call  func(i *Interpreter, c *Channel, token *l.Token, arguments []*Value) (*Value, error)
+}
+
+var channelMethods = map[string]channelMethod{
+	"send":  {1, channelSend},
+	"recv":  {0, channelRecv},
+	"close": {0, channelClose},
+}
+
+// channelProperty returns the method `name` of a channel, bound to it
+func channelProperty(c *Channel, name *l.Token) (*Value, error) {
+	method, ok := channelMethods[name.Value.(string)]
+	if !ok {
+		return nil, NewRuntimeError(name, "Undefined property '"+name.Value.(string)+"' of channel.")
+	}
+	return NewValue(&NativeFunction{
+		Name:  name.Value.(string),
+		Arity: method.arity,
+		Call: func(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error) {
+			return method.call(i, c, token, arguments)
+		},
+	}), nil
+}
+
+func channelSend(i *Interpreter, c *Channel, token *l.Token, arguments []*Value) (*Value, error) {
+	scheduler.Lock()
+	sent, err := c.trySend(arguments[0])
+	if err != nil || sent {
+		scheduler.Unlock()
+		if err != nil {
+			return nil, NewRuntimeError(token, err.Error())
+		}
+		return NewValue(nil), nil
+	}
+	w := newWaiter(i.tasks, token)
+	w.register(c, operation{index: 0, value: arguments[0]}, true)
+	if err := w.wait(); err != nil {
+		return nil, err
+	}
+	return NewValue(nil), nil
+}
+
+// recv returns nil once the channel is closed and drained
+func channelRecv(i *Interpreter, c *Channel, token *l.Token, arguments []*Value) (*Value, error) {
+	value, _, err := c.recv(i, token)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return NewValue(nil), nil
+	}
+	return value, nil
+}
+
+func channelClose(i *Interpreter, c *Channel, token *l.Token, arguments []*Value) (*Value, error) {
+	scheduler.Lock()
+	defer scheduler.Unlock()
+	if c.closed {
+		return nil, NewRuntimeError(token, "Channel is already closed.")
+	}
+	c.closed = true
+	// Blocked receivers get nil and blocked senders fail
+	receivers, senders := c.receivers, c.senders
+	c.receivers, c.senders = nil, nil
+	for _, receiver := range receivers {
+		receiver.waiter.wake(receiver.index, nil, false, nil)
+	}
+	for _, sender := range senders {
+		sender.waiter.wake(sender.index, nil, false, errClosedChannel)
+	}
+	return NewValue(nil), nil
+}
+
+// recv receives a value, `ok` is false when the channel is closed
+func (c *Channel) recv(i *Interpreter, token *l.Token) (value *Value, ok bool, err error) {
+	scheduler.Lock()
+	if value, ok, ready := c.tryRecv(); ready {
+		scheduler.Unlock()
+		return value, ok, nil
+	}
+	w := newWaiter(i.tasks, token)
+	w.register(c, operation{index: 0}, false)
+	if err := w.wait(); err != nil {
+		return nil, false, err
+	}
+	return w.value, w.ok, nil
+}
+
+// trySend sends a value without blocking, to the first blocked receiver or
+// else into the buffer. It reports whether the value was sent.
+func (c *Channel) trySend(value *Value) (bool, error) {
+	if c.closed {
+		return false, errClosedChannel
+	}
+	if len(c.receivers) != 0 {
+		receiver := c.receivers[0]
+		receiver.waiter.wake(receiver.index, value, true, nil)
+		return true, nil
+	}
+	if len(c.buffer) < c.capacity {
+		c.buffer = append(c.buffer, value)
+		return true, nil
+	}
+	return false, nil
+}
+
+// tryRecv receives a value without blocking, `ready` is false when it
+// would block
+func (c *Channel) tryRecv() (value *Value, ok bool, ready bool) {
+	if len(c.buffer) != 0 {
+		value, c.buffer = c.buffer[0], c.buffer[1:]
+		// The first blocked sender takes the freed slot
+		if len(c.senders) != 0 {
+			sender := c.senders[0]
+			c.buffer = append(c.buffer, sender.value)
+			sender.waiter.wake(sender.index, nil, false, nil)
+		}
+		return value, true, true
+	}
+	if len(c.senders) != 0 {
+		sender := c.senders[0]
+		sender.waiter.wake(sender.index, nil, false, nil)
+		return sender.value, true, true
+	}
+	if c.closed {
+		return nil, false, true
+	}
+	return nil, false, false
+}
+
+type waitGroupMethod struct {
+	arity int
+	call  func(i *Interpreter, w *WaitGroup, token *l.Token, arguments []*Value) (*Value, error)
+}
+
+var waitGroupMethods = map[string]waitGroupMethod{
+	"add":  {-1, waitGroupAdd},
+	"done": {0, waitGroupDone},
+	"wait": {0, waitGroupWait},
+}
+
+// waitGroupProperty returns the method `name` of a wait group, bound to it
+func waitGroupProperty(w *WaitGroup, name *l.Token) (*Value, error) {
+	method, ok := waitGroupMethods[name.Value.(string)]
+	if !ok {
+		return nil, NewRuntimeError(name, "Undefined property '"+name.Value.(string)+"' of wait group.")
+	}
+	return NewValue(&NativeFunction{
+		Name:  name.Value.(string),
+		Arity: method.arity,
+		Call: func(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error) {
+			return method.call(i, w, token, arguments)
+		},
+	}), nil
+}
+
+// add(delta?) adds delta, 1 by default, to the counter
+func waitGroupAdd(i *Interpreter, w *WaitGroup, token *l.Token, arguments []*Value) (*Value, error) {
+	if len(arguments) > 1 {
+		return nil, NewRuntimeError(token, "add expects an optional delta.")
+	}
+	delta := 1
+	if len(arguments) == 1 {
+		var err error
+		if delta, err = toInteger(arguments[0], token); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.add(delta); err != nil {
+		return nil, NewRuntimeError(token, err.Error())
+	}
+	return NewValue(nil), nil
+}
+
+func waitGroupDone(i *Interpreter, w *WaitGroup, token *l.Token, arguments []*Value) (*Value, error) {
+	if err := w.add(-1); err != nil {
+		return nil, NewRuntimeError(token, err.Error())
+	}
+	return NewValue(nil), nil
+}
+
+// wait blocks until the counter is zero
+func waitGroupWait(i *Interpreter, w *WaitGroup, token *l.Token, arguments []*Value) (*Value, error) {
+	scheduler.Lock()
+	if w.count == 0 {
+		scheduler.Unlock()
+		return NewValue(nil), nil
+	}
+	waiter := newWaiter(i.tasks, token)
+	waiter.group = w
+	w.waiters = append(w.waiters, waiter)
+	if err := waiter.wait(); err != nil {
+		return nil, err
+	}
+	return NewValue(nil), nil
+}
+
+func (w *WaitGroup) add(delta int) error {
+	scheduler.Lock()
+	defer scheduler.Unlock()
+	if w.count+delta < 0 {
+		return errors.New("Wait group counter can't be negative.")
+	}
+	w.count += delta
+	if w.count == 0 {
+		waiters := w.waiters
+		w.waiters = nil
+		for _, waiter := range waiters {
+			waiter.wake(0, nil, false, nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package interpreter
+
+import (
+	"math/rand"
+	"sync"
+
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+// scheduler guards the channels, the wait groups and the blocked tasks of
+// every program
+var scheduler sync.Mutex
+
+// tasks are the goroutines spawned by a program. The first runtime error
+// of a task fails the program: it's returned by the operations blocked on
+// a channel or a wait group, and by Run once the tasks are over.
+type tasks struct {
+	running sync.WaitGroup
+	failure sync.Once
+	err     error
+	failed  chan struct{}
+
+	// Guarded by the scheduler: the tasks not over yet, the main one
+	// included, and the blocked ones. Once all of them are blocked none
+	// can wake the others, the program is deadlocked.
+	live    int
+	blocked []*waiter
+}
+
+func newTasks() *tasks {
+	return &tasks{failed: make(chan struct{}), live: 1}
+}
+
+func (t *tasks) fail(err error) {
+	t.failure.Do(func() {
+		t.err = err
+		close(t.failed)
+	})
+}
+
+// wait waits for every task and returns the error of the first failing one
+func (t *tasks) wait() error {
+	// The main task is over until the next run
+	t.exit()
+	t.running.Wait()
+	scheduler.Lock()
+	t.live++
+	scheduler.Unlock()
+	select {
+	case <-t.failed:
+		return t.err
+	default:
+		return nil
+	}
+}
+
+// exit ends a task, failing the program when the remaining ones are all
+// blocked
+func (t *tasks) exit() {
+	scheduler.Lock()
+	defer scheduler.Unlock()
+	t.live--
+	if t.live != 0 && len(t.blocked) == t.live {
+		t.fail(NewRuntimeError(t.blocked[0].token, "Deadlock: all tasks are blocked."))
+	}
+}
+
+// waiter is a task blocked on channel operations or on a wait group. The
+// first operation to complete wakes it with its result.
+type waiter struct {
+	tasks *tasks
+	token *l.Token
+	awake chan struct{} // Closed once woken
+	woken bool
+
+	chosen int // Index of the completed operation
+	value  *Value
+	ok     bool
+	err    error
+
+	channels []*Channel // Where the operations are registered
+	group    *WaitGroup
+}
+
+func newWaiter(tasks *tasks, token *l.Token) *waiter {
+	return &waiter{tasks: tasks, token: token, awake: make(chan struct{})}
+}
+
+// register adds a blocked send or receive of the waiter to `c`
+func (w *waiter) register(c *Channel, op operation, send bool) {
+	op.waiter = w
+	if send {
+		c.senders = append(c.senders, op)
+	} else {
+		c.receivers = append(c.receivers, op)
+	}
+	w.channels = append(w.channels, c)
+}
+
+// wait blocks the task until an operation wakes it. It's called with the
+// scheduler locked and unlocks it.
+func (w *waiter) wait() error {
+	t := w.tasks
+	t.blocked = append(t.blocked, w)
+	if len(t.blocked) == t.live {
+		w.remove()
+		scheduler.Unlock()
+		err := NewRuntimeError(w.token, "Deadlock: all tasks are blocked.")
+		t.fail(err)
+		return err
+	}
+	scheduler.Unlock()
+
+	select {
+	case <-w.awake:
+	case <-t.failed:
+	}
+	scheduler.Lock()
+	defer scheduler.Unlock()
+	if !w.woken {
+		w.remove()
+		return t.err
+	}
+	if w.err != nil {
+		return NewRuntimeError(w.token, w.err.Error())
+	}
+	return nil
+}
+
+// wake completes the operation `chosen` of the waiter, the scheduler must
+// be locked
+func (w *waiter) wake(chosen int, value *Value, ok bool, err error) {
+	w.remove()
+	w.chosen, w.value, w.ok, w.err = chosen, value, ok, err
+	close(w.awake)
+}
+
+// remove unregisters the operations of the waiter, which no longer blocks
+func (w *waiter) remove() {
+	w.woken = true
+	for _, c := range w.channels {
+		c.senders = removeOperations(c.senders, w)
+		c.receivers = removeOperations(c.receivers, w)
+	}
+	if w.group != nil {
+		waiters := make([]*waiter, 0, len(w.group.waiters))
+		for _, waiter := range w.group.waiters {
+			if waiter != w {
+				waiters = append(waiters, waiter)
+			}
+		}
+		w.group.waiters = waiters
+	}
+	blocked := make([]*waiter, 0, len(w.tasks.blocked))
+	for _, waiter := range w.tasks.blocked {
+		if waiter != w {
+			blocked = append(blocked, waiter)
+		}
+	}
+	w.tasks.blocked = blocked
+}
+
+func removeOperations(operations []operation, w *waiter) []operation {
+	kept := make([]operation, 0, len(operations))
+	for _, op := range operations {
+		if op.waiter != w {
+			kept = append(kept, op)
+		}
+	}
+	return kept
+}
+
+// fork creates an interpreter for another goroutine. It shares the output,
+// coverage and tasks of `i` but has its own control flow state.
+func (i *Interpreter) fork() *Interpreter {
+	return &Interpreter{
+		env:      i.env,
+		coverage: i.coverage,
+		stdout:   i.stdout,
+		tasks:    i.tasks,
+		steps:    i.steps,
+		owner:    i.owner,
+	}
+}
+
+// ---------------- Spawn Statement ----------------
+// executeSpawnStmt evaluates the callee and arguments on the current
+// goroutine, then calls the function on a new one
+func (i *Interpreter) executeSpawnStmt(t *parser.SpawnStmt) error {
+	callee, arguments, named, err := i.evaluateCallParts(t.Call)
+	if err != nil {
+		return err
+	}
+	task, tasks := i.fork(), i.tasks
+	tasks.running.Add(1)
+	scheduler.Lock()
+	tasks.live++
+	scheduler.Unlock()
+	go func() {
+		defer tasks.running.Done()
+		defer tasks.exit()
+		if _, err := task.call(callee, arguments, named, t.Call.Paren); err != nil {
+			tasks.fail(err)
+		}
+	}()
+	return nil
+}
+
+// ---------------- Select Statement ----------------
+func (i *Interpreter) executeSelectStmt(t *parser.SelectStmt) error {
+	operations := make([]selectOperation, 0, len(t.Cases))
+	for _, selectCase := range t.Cases {
+		value, err := i.Evaluate(selectCase.Channel)
+		if err != nil {
+			return err
+		}
+		channel, ok := value.Data.(*Channel)
+		if !ok {
+			return NewRuntimeError(selectCase.Token, "Expected a channel but got "+value.Repr()+".")
+		}
+		if selectCase.Value == nil {
+			operations = append(operations, selectOperation{channel: channel})
+			continue
+		}
+		sent, err := i.Evaluate(selectCase.Value)
+		if err != nil {
+			return err
+		}
+		operations = append(operations, selectOperation{channel: channel, send: true, value: sent})
+	}
+
+	chosen, received, ok, err := i.selectChannels(t.Token, operations, t.Default == nil)
+	if err != nil {
+		return err
+	}
+	if chosen < 0 {
+		return i.Execute(t.Default)
+	}
+
+	selectCase := t.Cases[chosen]
+	oldEnv := i.env
+	i.env = NewEnvironment(i.env)
+	defer func(env *Environment) {
+		i.env = env
+	}(oldEnv)
+	if selectCase.Name != nil {
+		value := NewValue(nil)
+		if ok {
+			value = received
+		}
+		i.env.define(selectCase.Name, value)
+	}
+	return i.Execute(selectCase.Body)
+}
+
+// selectOperation is the send or receive of a select case
+type selectOperation struct {
+	channel *Channel
+	send    bool
+	value   *Value
+}
+
+// selectChannels performs one of the operations, chosen at random among
+// the ready ones. When none is ready it blocks until one is, or returns -1
+// when `block` is false.
+func (i *Interpreter) selectChannels(token *l.Token, operations []selectOperation, block bool) (chosen int, value *Value, ok bool, err error) {
+	scheduler.Lock()
+	for _, j := range rand.Perm(len(operations)) {
+		op := operations[j]
+		if !op.send {
+			if value, ok, ready := op.channel.tryRecv(); ready {
+				scheduler.Unlock()
+				return j, value, ok, nil
+			}
+			continue
+		}
+		sent, err := op.channel.trySend(op.value)
+		if err != nil || sent {
+			scheduler.Unlock()
+			if err != nil {
+				return 0, nil, false, NewRuntimeError(token, err.Error())
+			}
+			return j, nil, false, nil
+		}
+	}
+	if !block {
+		scheduler.Unlock()
+		return -1, nil, false, nil
+	}
+
+	w := newWaiter(i.tasks, token)
+	for j, op := range operations {
+		w.register(op.channel, operation{index: j, value: op.value}, op.send)
+	}
+	if err := w.wait(); err != nil {
+		return 0, nil, false, err
+	}
+	return w.chosen, w.value, w.ok, nil
+}
@@ -0,0 +1,409 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/debugg-er/lox/src/parser"
+)
+
+// Coverage records which statements of a program were executed and which
+// way every branching condition (if, while, for) evaluated. A for-in loop
+// takes its true branch for each iteration and its false one when done.
+type Coverage struct {
+	mutex      sync.Mutex // Spawned tasks record their hits concurrently
+	statements map[parser.Stmt]*stmtCoverage
+	branches   map[parser.Stmt]*branchCoverage
+	order      []parser.Stmt // Branching statements in source order
+}
+
+type stmtCoverage struct {
+	line int
+	hits int
+}
+
+type branchCoverage struct {
+	line       int
+	reached    bool
+	trueCount  int
+	falseCount int
+}
+
+// NewCoverage registers every statement of the program, including the
+// ones nested in function bodies, so that unexecuted code is reported too.
+func NewCoverage(statements []parser.Stmt) *Coverage {
+	c := &Coverage{
+		statements: make(map[parser.Stmt]*stmtCoverage),
+		branches:   make(map[parser.Stmt]*branchCoverage),
+		order:      make([]parser.Stmt, 0),
+	}
+	for _, stmt := range statements {
+		c.registerStmt(stmt)
+	}
+	return c
+}
+
+func (c *Coverage) registerStmt(stmt parser.Stmt) {
+	if stmt == nil {
+		return
+	}
+	if line, ok := stmtLine(stmt); ok {
+		c.statements[stmt] = &stmtCoverage{line: line}
+	}
+
+	switch stmt := stmt.(type) {
+	case *parser.PrintStmt:
+		c.registerExpr(stmt.Expr)
+	case *parser.ExprStmt:
+		c.registerExpr(stmt.Expr)
+	case *parser.VarStmt:
+		c.registerExpr(stmt.Initilizer)
+	case *parser.ReturnStmt:
+		c.registerExpr(stmt.Expr)
+	case *parser.YieldStmt:
+		c.registerExpr(stmt.Expr)
+	case *parser.BlockStmt:
+		for _, child := range stmt.Declarations {
+			c.registerStmt(child)
+		}
+	case *parser.IfStmt:
+		c.registerBranch(stmt, stmt.Token.Line)
+		c.registerExpr(stmt.Condition)
+		c.registerStmt(stmt.ThenStmt)
+		c.registerStmt(stmt.ElseStmt)
+	case *parser.WhileStmt:
+		c.registerBranch(stmt, stmt.Token.Line)
+		c.registerExpr(stmt.Condition)
+		c.registerStmt(stmt.Body)
+	case *parser.ForStmt:
+		if stmt.Condition != nil {
+			c.registerBranch(stmt, stmt.Token.Line)
+		}
+		c.registerStmt(stmt.Initialization)
+		c.registerExpr(stmt.Condition)
+		c.registerExpr(stmt.Updation)
+		c.registerStmt(stmt.Body)
+	case *parser.ForInStmt:
+		c.registerBranch(stmt, stmt.Token.Line)
+		c.registerExpr(stmt.Iterable)
+		c.registerStmt(stmt.Body)
+	case *parser.DoWhileStmt:
+		c.registerBranch(stmt, stmt.Token.Line)
+		c.registerStmt(stmt.Body)
+		c.registerExpr(stmt.Condition)
+	case *parser.LoopStmt:
+		c.registerStmt(stmt.Body)
+	case *parser.MatchStmt:
+		c.registerExpr(stmt.Subject)
+		for _, matchCase := range stmt.Cases {
+			c.registerStmt(matchCase.Body)
+		}
+	case *parser.SpawnStmt:
+		c.registerExpr(stmt.Call)
+	case *parser.SelectStmt:
+		for _, selectCase := range stmt.Cases {
+			c.registerExpr(selectCase.Channel)
+			c.registerExpr(selectCase.Value)
+			c.registerStmt(selectCase.Body)
+		}
+		c.registerStmt(stmt.Default)
+	case *parser.FuncStmt:
+		for _, parameter := range stmt.Parameters {
+			c.registerExpr(parameter.Default)
+		}
+		c.registerStmt(stmt.Body)
+	}
+}
+
+// Function literals may appear anywhere in an expression, their bodies
+// have to be registered as well.
+func (c *Coverage) registerExpr(expr parser.Expr) {
+	switch expr := expr.(type) {
+	case *parser.UnaryExpr:
+		c.registerExpr(expr.Operand)
+	case *parser.BinaryExpr:
+		c.registerExpr(expr.Left)
+		c.registerExpr(expr.Right)
+	case *parser.AssignExpr:
+		c.registerExpr(expr.Value)
+	case *parser.SetExpr:
+		c.registerExpr(expr.Target)
+		c.registerExpr(expr.Value)
+	case *parser.DestructureExpr:
+		c.registerExpr(expr.Target)
+		c.registerExpr(expr.Value)
+	case *parser.SpreadExpr:
+		c.registerExpr(expr.Value)
+	case *parser.IncrementExpr:
+		c.registerExpr(expr.Target)
+	case *parser.FuncExpr:
+		c.registerStmt(expr.FuncStmt)
+	case *parser.CallExpr:
+		c.registerExpr(expr.Callee)
+		for _, argument := range expr.Arguments {
+			c.registerExpr(argument)
+		}
+		for _, argument := range expr.NamedArguments {
+			c.registerExpr(argument.Value)
+		}
+	case *parser.ListExpr:
+		for _, element := range expr.Elements {
+			c.registerExpr(element)
+		}
+	case *parser.IndexExpr:
+		c.registerExpr(expr.Object)
+		c.registerExpr(expr.Index)
+	case *parser.SliceExpr:
+		c.registerExpr(expr.Object)
+		c.registerExpr(expr.Start)
+		c.registerExpr(expr.End)
+	case *parser.GetExpr:
+		c.registerExpr(expr.Object)
+	case *parser.OptionalChainExpr:
+		c.registerExpr(expr.Chain)
+	case *parser.ConditionalExpr:
+		c.registerExpr(expr.Condition)
+		c.registerExpr(expr.Then)
+		c.registerExpr(expr.Else)
+	case *parser.RangeExpr:
+		c.registerExpr(expr.Start)
+		c.registerExpr(expr.End)
+		c.registerExpr(expr.Step)
+	case *parser.MapExpr:
+		for j, key := range expr.Keys {
+			c.registerExpr(key)
+			c.registerExpr(expr.Values[j])
+		}
+	case *parser.InterpolationExpr:
+		for _, part := range expr.Parts {
+			c.registerExpr(part)
+		}
+	}
+}
+
+func (c *Coverage) registerBranch(stmt parser.Stmt, line int) {
+	c.branches[stmt] = &branchCoverage{line: line}
+	c.order = append(c.order, stmt)
+}
+
+func (c *Coverage) hitStmt(stmt parser.Stmt) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if coverage := c.statements[stmt]; coverage != nil {
+		coverage.hits++
+	}
+}
+
+func (c *Coverage) hitBranch(stmt parser.Stmt, taken bool) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	coverage := c.branches[stmt]
+	if coverage == nil {
+		return
+	}
+	coverage.reached = true
+	if taken {
+		coverage.trueCount++
+	} else {
+		coverage.falseCount++
+	}
+}
+
+// lines returns the execution count of every instrumented line. When many
+// statements share a line, the line count is the highest of them.
+func (c *Coverage) lines() ([]int, map[int]int) {
+	counts := make(map[int]int)
+	for _, stmt := range c.statements {
+		if hits, ok := counts[stmt.line]; !ok || stmt.hits > hits {
+			counts[stmt.line] = stmt.hits
+		}
+	}
+	lines := make([]int, 0, len(counts))
+	for line := range counts {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines, counts
+}
+
+func (c *Coverage) sortedBranches() []*branchCoverage {
+	branches := make([]*branchCoverage, 0, len(c.order))
+	for _, stmt := range c.order {
+		branches = append(branches, c.branches[stmt])
+	}
+	sort.SliceStable(branches, func(a, b int) bool {
+		return branches[a].line < branches[b].line
+	})
+	return branches
+}
+
+// WriteLCOV writes the coverage in the LCOV tracefile format, `source` is
+// the path reported as the source file of the record.
+func (c *Coverage) WriteLCOV(w io.Writer, source string) error {
+	var out strings.Builder
+	out.WriteString("TN:\n")
+	fmt.Fprintf(&out, "SF:%s\n", source)
+
+	branchesFound, branchesHit := 0, 0
+	for block, branch := range c.sortedBranches() {
+		for index, count := range []int{branch.trueCount, branch.falseCount} {
+			taken := "-"
+			if branch.reached {
+				taken = fmt.Sprint(count)
+			}
+			fmt.Fprintf(&out, "BRDA:%d,%d,%d,%s\n", branch.line, block, index, taken)
+			branchesFound++
+			if count > 0 {
+				branchesHit++
+			}
+		}
+	}
+	fmt.Fprintf(&out, "BRF:%d\n", branchesFound)
+	fmt.Fprintf(&out, "BRH:%d\n", branchesHit)
+
+	lines, counts := c.lines()
+	linesHit := 0
+	for _, line := range lines {
+		fmt.Fprintf(&out, "DA:%d,%d\n", line, counts[line])
+		if counts[line] > 0 {
+			linesHit++
+		}
+	}
+	fmt.Fprintf(&out, "LF:%d\n", len(lines))
+	fmt.Fprintf(&out, "LH:%d\n", linesHit)
+	out.WriteString("end_of_record\n")
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// Summary returns a human readable report of line and branch coverage
+// along with the lines and branches that were never exercised.
+func (c *Coverage) Summary(source string) string {
+	var out strings.Builder
+
+	lines, counts := c.lines()
+	uncovered := make([]int, 0)
+	for _, line := range lines {
+		if counts[line] == 0 {
+			uncovered = append(uncovered, line)
+		}
+	}
+	branches := c.sortedBranches()
+	branchesHit := 0
+	partial := make([]string, 0)
+	for _, branch := range branches {
+		if branch.trueCount > 0 {
+			branchesHit++
+		}
+		if branch.falseCount > 0 {
+			branchesHit++
+		}
+		switch {
+		case branch.trueCount == 0 && branch.falseCount == 0:
+			partial = append(partial, fmt.Sprintf("%d (never evaluated)", branch.line))
+		case branch.trueCount == 0:
+			partial = append(partial, fmt.Sprintf("%d (never true)", branch.line))
+		case branch.falseCount == 0:
+			partial = append(partial, fmt.Sprintf("%d (never false)", branch.line))
+		}
+	}
+
+	fmt.Fprintf(&out, "Coverage of %s\n", source)
+	fmt.Fprintf(&out, "  Lines:    %s\n", ratio(len(lines)-len(uncovered), len(lines)))
+	fmt.Fprintf(&out, "  Branches: %s\n", ratio(branchesHit, len(branches)*2))
+	if len(uncovered) != 0 {
+		fmt.Fprintf(&out, "  Uncovered lines: %s\n", lineRanges(uncovered))
+	}
+	if len(partial) != 0 {
+		fmt.Fprintf(&out, "  Partial branches: %s\n", strings.Join(partial, ", "))
+	}
+	return out.String()
+}
+
+func ratio(hit int, found int) string {
+	if found == 0 {
+		return "0/0 (100.0%)"
+	}
+	return fmt.Sprintf("%d/%d (%.1f%%)", hit, found, float64(hit)*100/float64(found))
+}
+
+// lineRanges collapses sorted line numbers into ranges, e.g. "3, 5-7"
+func lineRanges(lines []int) string {
+	ranges := make([]string, 0)
+	for start := 0; start < len(lines); {
+		end := start
+		for end+1 < len(lines) && lines[end+1] == lines[end]+1 {
+			end++
+		}
+		if start == end {
+			ranges = append(ranges, fmt.Sprint(lines[start]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", lines[start], lines[end]))
+		}
+		start = end + 1
+	}
+	return strings.Join(ranges, ", ")
+}
+
+func stmtLine(stmt parser.Stmt) (int, bool) {
+	switch stmt := stmt.(type) {
+	case *parser.PrintStmt:
+		return stmt.Token.Line, true
+	case *parser.ExprStmt:
+		return stmt.Token.Line, true
+	case *parser.VarStmt:
+		switch pattern := stmt.Pattern.(type) {
+		case *parser.ListPattern:
+			return pattern.Bracket.Line, true
+		case *parser.MapPattern:
+			return pattern.Brace.Line, true
+		}
+		return stmt.Name.Line, true
+	case *parser.IfStmt:
+		return stmt.Token.Line, true
+	case *parser.WhileStmt:
+		return stmt.Token.Line, true
+	case *parser.DoWhileStmt:
+		return stmt.Token.Line, true
+	case *parser.LoopStmt:
+		return stmt.Token.Line, true
+	case *parser.ForStmt:
+		return stmt.Token.Line, true
+	case *parser.ForInStmt:
+		return stmt.Token.Line, true
+	case *parser.MatchStmt:
+		return stmt.Token.Line, true
+	case *parser.SpawnStmt:
+		return stmt.Token.Line, true
+	case *parser.SelectStmt:
+		return stmt.Token.Line, true
+	case *parser.BreakStmt:
+		return stmt.Token.Line, true
+	case *parser.ContinueStmt:
+		return stmt.Token.Line, true
+	case *parser.ReturnStmt:
+		return stmt.Token.Line, true
+	case *parser.YieldStmt:
+		return stmt.Token.Line, true
+	case *parser.FuncStmt:
+		// Only declarations are executed, not function expressions
+		if stmt.Name == nil {
+			return 0, false
+		}
+		return stmt.Name.Line, true
+	default:
+		// Blocks are containers, their lines are covered by the
+		// statements they hold
+		return 0, false
+	}
+}
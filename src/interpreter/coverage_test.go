@@ -0,0 +1,110 @@
+package interpreter
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+const coverageSource = `var n = 0;
+for (var i = 0; i < 3; i++) {
+  if (i == 1) {
+    n = n + 10;
+  } else {
+    n = n + 1;
+  }
+}
+if (n > 100) {
+  print "big";
+}
+print n;
+`
+
+func runCoverage(t *testing.T, source string) *Coverage {
+	tokens, err := l.NewLexer().Parse(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, errs := parser.NewParser().Parse(tokens)
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	coverage := NewCoverage(statements)
+	i := NewInterpreter()
+	i.SetOutput(io.Discard)
+	i.SetCoverage(coverage)
+	if err := i.Run(statements); err != nil {
+		t.Fatal(err)
+	}
+	return coverage
+}
+
+func TestCoverageLCOV(t *testing.T) {
+	coverage := runCoverage(t, coverageSource)
+	var out strings.Builder
+	if err := coverage.WriteLCOV(&out, "program.lox"); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"TN:",
+		"SF:program.lox",
+		// The loop condition holds 3 times and fails once, the branch of
+		// the if inside takes each way, the last if is never true
+		"BRDA:2,0,0,3",
+		"BRDA:2,0,1,1",
+		"BRDA:3,1,0,1",
+		"BRDA:3,1,1,2",
+		"BRDA:9,2,0,0",
+		"BRDA:9,2,1,1",
+		"BRF:6",
+		"BRH:5",
+		"DA:1,1",
+		"DA:2,1",
+		"DA:3,3",
+		"DA:4,1",
+		"DA:6,2",
+		"DA:9,1",
+		"DA:10,0",
+		"DA:12,1",
+		"LF:8",
+		"LH:7",
+		"end_of_record",
+	}, "\n") + "\n"
+	if out.String() != expected {
+		t.Errorf("expected report\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestCoverageSummary(t *testing.T) {
+	coverage := runCoverage(t, coverageSource)
+	expected := "Coverage of program.lox\n" +
+		"  Lines:    7/8 (87.5%)\n" +
+		"  Branches: 5/6 (83.3%)\n" +
+		"  Uncovered lines: 10\n" +
+		"  Partial branches: 9 (never true)\n"
+	if summary := coverage.Summary("program.lox"); summary != expected {
+		t.Errorf("expected summary\n%s\ngot\n%s", expected, summary)
+	}
+}
+
+// Branches of functions which are never called are reported as never
+// evaluated, in the LCOV report as well
+func TestCoverageUncalledFunction(t *testing.T) {
+	coverage := runCoverage(t, "fun f(x) {\n  if (x) return 1;\n  return 2;\n}\n")
+	var out strings.Builder
+	if err := coverage.WriteLCOV(&out, "program.lox"); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"BRDA:2,0,0,-", "BRDA:2,0,1,-", "BRH:0", "DA:2,0", "DA:3,0", "LH:1"} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected %q in report\n%s", line, out.String())
+		}
+	}
+	summary := coverage.Summary("program.lox")
+	if !strings.Contains(summary, "  Branches: 0/2 (0.0%)\n") || !strings.Contains(summary, "2 (never evaluated)") {
+		t.Errorf("unexpected summary\n%s", summary)
+	}
+}
@@ -0,0 +1,325 @@
+package interpreter
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	l "github.com/debugg-er/lox/src/lexer"
+)
+
+// divisionScale is the number of decimal places a quotient of decimals is
+// rounded to, unless its operands have more
+const divisionScale = 16
+
+// Decimal is an exact base 10 number, the integer `unscaled` divided by
+// 10^scale. Its scale is kept by operations: 1.10d * 2 is 2.20.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// parseDecimal parses a literal such as `-19.99` or `6.02e23`
+func parseDecimal(literal string) (*Decimal, bool) {
+	exponent := 0
+	if e := strings.IndexAny(literal, "eE"); e >= 0 {
+		var err error
+		if exponent, err = strconv.Atoi(literal[e+1:]); err != nil {
+			return nil, false
+		}
+		literal = literal[:e]
+	}
+	scale := 0
+	if dot := strings.IndexByte(literal, '.'); dot >= 0 {
+		scale = len(literal) - dot - 1
+		literal = literal[:dot] + literal[dot+1:]
+	}
+	unscaled, ok := new(big.Int).SetString(literal, 10)
+	if !ok {
+		return nil, false
+	}
+	// 1.5e2 is 150, not 150.0
+	scale -= exponent
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return &Decimal{unscaled, scale}, true
+}
+
+// toDecimal converts an integer or a decimal to a decimal, it returns
+// false for other values
+func toDecimal(value Value) (*Decimal, bool) {
+	switch value.DataType {
+	case DECIMAL_DT:
+		return value.Data.(*Decimal), true
+	case BOOLEAN_DT, INTEGER_DT, BIG_INTEGER_DT:
+		return &Decimal{toBigInt(value), 0}, true
+	default:
+		return nil, false
+	}
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// rescale returns the unscaled value of `d` at a scale not less than its
+// own
+func (d *Decimal) rescale(scale int) *big.Int {
+	return new(big.Int).Mul(d.unscaled, pow10(scale-d.scale))
+}
+
+func (d *Decimal) Sign() int {
+	return d.unscaled.Sign()
+}
+
+func (d *Decimal) Cmp(other *Decimal) int {
+	scale := maxInt(d.scale, other.scale)
+	return d.rescale(scale).Cmp(other.rescale(scale))
+}
+
+func (d *Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.unscaled, pow10(d.scale))
+}
+
+func (d *Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+func (d *Decimal) IsInteger() bool {
+	return new(big.Int).Rem(d.unscaled, pow10(d.scale)).Sign() == 0
+}
+
+func (d *Decimal) String() string {
+	digits := new(big.Int).Abs(d.unscaled).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// trim removes the trailing zeros of `d` down to `scale` decimal places
+func (d *Decimal) trim(scale int) *Decimal {
+	unscaled := new(big.Int).Set(d.unscaled)
+	remainder, ten := new(big.Int), big.NewInt(10)
+	for current := d.scale; current > scale; current-- {
+		quotient, _ := new(big.Int).QuoRem(unscaled, ten, remainder)
+		if remainder.Sign() != 0 {
+			return &Decimal{unscaled, current}
+		}
+		unscaled = quotient
+	}
+	return &Decimal{unscaled, maxInt(scale, 0)}
+}
+
+// roundingMode tells which way a number is rounded when it lies between
+// two candidates
+type roundingMode string
+
+const (
+	halfEven roundingMode = "halfEven"
+	halfUp   roundingMode = "halfUp"
+	halfDown roundingMode = "halfDown"
+	up       roundingMode = "up"
+	down     roundingMode = "down"
+	ceiling  roundingMode = "ceiling"
+	floor    roundingMode = "floor"
+)
+
+var roundingModes = map[string]roundingMode{
+	"halfEven": halfEven,
+	"halfUp":   halfUp,
+	"halfDown": halfDown,
+	"up":       up,
+	"down":     down,
+	"ceiling":  ceiling,
+	"floor":    floor,
+}
+
+// roundQuotient returns n / d rounded to an integer, `up` and `down` being
+// away from and toward zero
+func roundQuotient(n *big.Int, d *big.Int, mode roundingMode) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(n, d, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+	sign := n.Sign() * d.Sign()
+	// Compares the remainder with half of the divisor
+	twice := new(big.Int).Abs(remainder)
+	half := twice.Lsh(twice, 1).Cmp(new(big.Int).Abs(d))
+	var away bool
+	switch mode {
+	case up:
+		away = true
+	case down:
+		away = false
+	case ceiling:
+		away = sign > 0
+	case floor:
+		away = sign < 0
+	case halfUp:
+		away = half >= 0
+	case halfDown:
+		away = half > 0
+	default:
+		away = half > 0 || half == 0 && quotient.Bit(0) == 1
+	}
+	if away {
+		quotient.Add(quotient, big.NewInt(int64(sign)))
+	}
+	return quotient
+}
+
+// round returns `d` with exactly `scale` decimal places
+func (d *Decimal) round(scale int, mode roundingMode) *Decimal {
+	if scale >= d.scale {
+		return &Decimal{d.rescale(scale), scale}
+	}
+	return &Decimal{roundQuotient(d.unscaled, pow10(d.scale-scale), mode), scale}
+}
+
+// divide returns a / b rounded half to even to `divisionScale` places, or
+// to the scale of an operand having more, without trailing zeros past the
+// scales of the operands
+func divide(a *Decimal, b *Decimal) *Decimal {
+	scale := maxInt(divisionScale, maxInt(a.scale, b.scale))
+	// a / b * 10^scale = a.unscaled * 10^(scale - a.scale + b.scale) / b.unscaled
+	numerator := new(big.Int).Mul(a.unscaled, pow10(scale-a.scale+b.scale))
+	quotient := &Decimal{roundQuotient(numerator, b.unscaled, halfEven), scale}
+	return quotient.trim(maxInt(a.scale, b.scale))
+}
+
+// decimalArithmetic applies an arithmetic operator to operands of which
+// one at least is a decimal and the other one an integer or a decimal
+func decimalArithmetic(operator l.TokenType, token *l.Token, left *Value, right *Value) (*Value, error) {
+	if operator == l.STAR_STAR {
+		return decimalPower(token, left, right)
+	}
+	a, okA := toDecimal(*left)
+	b, okB := toDecimal(*right)
+	if !okA || !okB {
+		return nil, NewRuntimeError(token, "Decimals can't be mixed with floats")
+	}
+	scale := maxInt(a.scale, b.scale)
+	switch operator {
+	case l.PLUS:
+		return NewValue(&Decimal{new(big.Int).Add(a.rescale(scale), b.rescale(scale)), scale}), nil
+	case l.MINUS:
+		return NewValue(&Decimal{new(big.Int).Sub(a.rescale(scale), b.rescale(scale)), scale}), nil
+	case l.STAR:
+		return NewValue(&Decimal{new(big.Int).Mul(a.unscaled, b.unscaled), a.scale + b.scale}), nil
+	}
+	if b.Sign() == 0 {
+		return nil, NewRuntimeError(token, "Division by zero")
+	}
+	if operator == l.PERCENT {
+		return NewValue(&Decimal{new(big.Int).Rem(a.rescale(scale), b.rescale(scale)), scale}), nil
+	}
+	return NewValue(divide(a, b)), nil
+}
+
+// decimalPower raises a decimal to an integer power
+func decimalPower(token *l.Token, left *Value, right *Value) (*Value, error) {
+	base, ok := toDecimal(*left)
+	if !ok {
+		return nil, NewRuntimeError(token, "Decimals can't be mixed with floats")
+	}
+	if right.DataType != INTEGER_DT && right.DataType != BOOLEAN_DT {
+		return nil, NewRuntimeError(token, "Exponent of a decimal must be an integer")
+	}
+	exponent := toInt(*right)
+	magnitude := exponent
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+	if powerTooLarge(base.unscaled, big.NewInt(magnitude)) || (base.scale != 0 && magnitude > maxShift) {
+		return nil, NewRuntimeError(token, "Exponent too large")
+	}
+	power := &Decimal{
+		new(big.Int).Exp(base.unscaled, big.NewInt(magnitude), nil),
+		base.scale * int(magnitude),
+	}
+	if exponent >= 0 {
+		return NewValue(power), nil
+	}
+	if power.Sign() == 0 {
+		return nil, NewRuntimeError(token, "Division by zero")
+	}
+	return NewValue(divide(&Decimal{big.NewInt(1), 0}, power)), nil
+}
+
+// decimalProperty returns the method `name` of a decimal, bound to it
+func decimalProperty(d *Decimal, name *l.Token) (*Value, error) {
+	if name.Value != "round" {
+		return nil, NewRuntimeError(name, "Undefined property '"+name.Value.(string)+"' of decimal.")
+	}
+	return NewValue(&NativeFunction{
+		Name:  "round",
+		Arity: -1,
+		Call: func(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error) {
+			return decimalRound(d, token, arguments)
+		},
+	}), nil
+}
+
+// decimalRound is `d.round(places, mode)`, mode defaulting to "halfEven"
+func decimalRound(d *Decimal, token *l.Token, arguments []*Value) (*Value, error) {
+	if len(arguments) == 0 || len(arguments) > 2 {
+		return nil, NewRuntimeError(token, "Expected 1 to 2 arguments but got "+strconv.Itoa(len(arguments))+".")
+	}
+	places, err := toInteger(arguments[0], token)
+	if err != nil {
+		return nil, err
+	}
+	if places < 0 {
+		return nil, NewRuntimeError(token, "Decimal places can't be negative.")
+	}
+	mode := halfEven
+	if len(arguments) == 2 {
+		name, err := expectString(arguments[1], token)
+		if err != nil {
+			return nil, err
+		}
+		if mode = roundingModes[name]; mode == "" {
+			return nil, NewRuntimeError(token, "Unknown rounding mode '"+name+"'.")
+		}
+	}
+	return NewValue(d.round(places, mode)), nil
+}
+
+// nativeDecimal converts a string, an integer or a float to a decimal, a
+// float by its shortest representation: decimal(0.1) is 0.1
+func nativeDecimal(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error) {
+	var literal string
+	switch value := arguments[0].Data.(type) {
+	case *Decimal:
+		return arguments[0], nil
+	case string:
+		literal = strings.TrimPrefix(strings.TrimSpace(value), "+")
+	case float64:
+		literal = strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		if d, ok := toDecimal(*arguments[0]); ok {
+			return NewValue(d), nil
+		}
+	}
+	if d, ok := parseDecimal(literal); ok && !strings.HasPrefix(literal, "+") {
+		return NewValue(d), nil
+	}
+	return nil, NewRuntimeError(token, "Can't convert "+arguments[0].Repr()+" to a decimal.")
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,121 @@
+package interpreter
+
+import (
+	"fmt"
+
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+// destructure binds the names of a declaration pattern to the parts of
+// `value`, defining each of them with `define`. Unlike a case pattern, a
+// pattern which doesn't fit the value is an error.
+func destructure(pattern parser.Pattern, value *Value, define func(name *l.Token, value *Value)) error {
+	switch pattern := pattern.(type) {
+	case *parser.BindingPattern:
+		define(pattern.Name, value)
+	case *parser.ListPattern:
+		elements, err := unpack(value, len(pattern.Elements), pattern.Rest, pattern.Bracket)
+		if err != nil {
+			return err
+		}
+		for j, element := range pattern.Elements {
+			if err := destructure(element, elements[j], define); err != nil {
+				return err
+			}
+		}
+		if pattern.RestName != nil {
+			rest := make([]*Value, len(elements)-len(pattern.Elements))
+			copy(rest, elements[len(pattern.Elements):])
+			define(pattern.RestName, NewValue(NewList(rest)))
+		}
+	case *parser.MapPattern:
+		m, ok := value.Data.(*Map)
+		if !ok {
+			return NewRuntimeError(pattern.Brace, "Can't destructure "+value.Repr()+" as a map.")
+		}
+		for j, key := range pattern.Keys {
+			entry, ok := m.Get(literalValue(key.Value))
+			if !ok {
+				return NewRuntimeError(pattern.Brace, "Missing key "+literalValue(key.Value).Repr()+" to destructure.")
+			}
+			if err := destructure(pattern.Values[j], entry, define); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unpack returns the elements of a list destructured into `count` targets,
+// or at least `count` of them with a rest
+func unpack(value *Value, count int, rest bool, bracket *l.Token) ([]*Value, error) {
+	list, ok := value.Data.(*List)
+	if !ok {
+		return nil, NewRuntimeError(bracket, "Can't destructure "+value.Repr()+" as a list.")
+	}
+	elements := list.Elements()
+	if rest && len(elements) < count {
+		return nil, NewRuntimeError(bracket, fmt.Sprintf("Expected at least %d values to destructure but got %d.", count, len(elements)))
+	}
+	if !rest && len(elements) != count {
+		return nil, NewRuntimeError(bracket, fmt.Sprintf("Expected %d values to destructure but got %d.", count, len(elements)))
+	}
+	return elements, nil
+}
+
+// evaluateDestructure assigns the elements of the value to the targets once
+// the value is evaluated, so `[a, b] = [b, a]` swaps a and b
+func (i *Interpreter) evaluateDestructure(e *parser.DestructureExpr) (*Value, error) {
+	value, err := i.Evaluate(e.Value)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.assignTargets(e.Target, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (i *Interpreter) assignTargets(target *parser.ListExpr, value *Value) error {
+	targets := target.Elements
+	var rest *parser.SpreadExpr
+	if count := len(targets); count != 0 {
+		if spread, ok := targets[count-1].(*parser.SpreadExpr); ok {
+			targets, rest = targets[:count-1], spread
+		}
+	}
+	elements, err := unpack(value, len(targets), rest != nil, target.Bracket)
+	if err != nil {
+		return err
+	}
+	if rest != nil {
+		remaining := make([]*Value, len(elements)-len(targets))
+		copy(remaining, elements[len(targets):])
+		// The rest is the last target, assigned the list of the remaining
+		// elements
+		elements = append(elements[:len(targets):len(targets)], NewValue(NewList(remaining)))
+		targets = append(targets[:len(targets):len(targets)], rest.Value)
+	}
+	for j, element := range targets {
+		switch element := element.(type) {
+		case *parser.ListExpr:
+			if err := i.assignTargets(element, elements[j]); err != nil {
+				return err
+			}
+			continue
+		case *parser.VariableExpr:
+			if element.Name.Value == "_" {
+				continue
+			}
+		}
+		ref, err := i.reference(element)
+		if err != nil {
+			return err
+		}
+		if err := ref.set(elements[j]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
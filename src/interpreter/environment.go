@@ -1,80 +1,89 @@
 package interpreter
 
 import (
+	"fmt"
+	"sync"
+	"weak"
+
 	l "github.com/debugg-er/lox/src/lexer"
-	"github.com/debugg-er/lox/src/parser"
 )
 
+// Environment is safe for concurrent use, a spawned task shares the
+// environments enclosing the function it runs
 type Environment struct {
-	store           map[string]*Value
-	enclosing       *Environment
-	loopableTarget  parser.Loopable   // Executor Target (ForStmt, WhileStmt)
-	returableTarget parser.Returnable // Executor Target (FuncStmt)
-	returnValue     *Value
+	mutex     sync.RWMutex
+	store     map[string]*Value
+	enclosing *Environment
+	// outer replaces enclosing in the environment of a generator call. The
+	// goroutine running the body must not keep the generator reachable
+	// through the environment of its declaration, which the generator and
+	// the functions declared in its body keep alive instead.
+	outer weak.Pointer[Environment]
+	// The declarations of the constants of the environment, by name
+	constants map[string]*l.Token
 }
 
 func NewEnvironment(enclosing *Environment) *Environment {
 	return &Environment{
-		store:          make(map[string]*Value),
-		enclosing:      enclosing,
-		loopableTarget: nil,
+		store:     make(map[string]*Value),
+		enclosing: enclosing,
 	}
 }
 
 func (e *Environment) define(variable *l.Token, value *Value) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
 	e.store[variable.Value.(string)] = value
+	delete(e.constants, variable.Value.(string))
+}
+
+// defineConstant defines a variable which can't be assigned
+func (e *Environment) defineConstant(variable *l.Token, value *Value) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.store[variable.Value.(string)] = value
+	if e.constants == nil {
+		e.constants = make(map[string]*l.Token)
+	}
+	e.constants[variable.Value.(string)] = variable
 }
 
 func (e *Environment) get(variable *l.Token) (*Value, error) {
 	varName := variable.Value.(string)
+	e.mutex.RLock()
 	value := e.store[varName]
+	e.mutex.RUnlock()
 	if value != nil {
 		return value, nil
 	}
-	if e.enclosing != nil {
-		return e.enclosing.get(variable)
+	if parent := e.parent(); parent != nil {
+		return parent.get(variable)
 	}
 	return nil, NewRuntimeError(variable, "Undefined variable '"+varName+"'.")
 }
 
 func (e *Environment) assign(variable *l.Token, value *Value) error {
 	varName := variable.Value.(string)
+	e.mutex.Lock()
+	if declaration := e.constants[varName]; declaration != nil {
+		e.mutex.Unlock()
+		return NewRuntimeError(variable, fmt.Sprintf("Can't assign to constant '%s' declared at line %d.", varName, declaration.Line))
+	}
 	if e.store[varName] != nil {
-		e.store[variable.Value.(string)] = value
+		e.store[varName] = value
+		e.mutex.Unlock()
 		return nil
 	}
-	if e.enclosing != nil {
-		return e.enclosing.assign(variable, value)
+	e.mutex.Unlock()
+	if parent := e.parent(); parent != nil {
+		return parent.assign(variable, value)
 	}
 	return NewRuntimeError(variable, "Undefined variable '"+varName+"'.")
 }
 
-func (e *Environment) getLoopableTarget() parser.Loopable {
-	if e.loopableTarget != nil {
-		return e.loopableTarget
-	}
-	if e.enclosing != nil {
-		return e.enclosing.getLoopableTarget()
-	}
-	return nil
-}
-
-func (e *Environment) getReturnableTarget() parser.Returnable {
-	if e.returableTarget != nil {
-		return e.returableTarget
-	}
-	if e.enclosing != nil {
-		return e.enclosing.getReturnableTarget()
-	}
-	return nil
-}
-
-func (e *Environment) getReturnableTargetEnv() *Environment {
-	if e.returableTarget != nil {
-		return e
-	}
+func (e *Environment) parent() *Environment {
 	if e.enclosing != nil {
-		return e.enclosing.getReturnableTargetEnv()
+		return e.enclosing
 	}
-	return nil
+	return e.outer.Value()
 }
@@ -15,6 +15,16 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("Line %d at '%s': %s\n", e.token.Line, e.token.Type, e.message)
 }
 
+// Line is the line of the source the error occurred at
+func (e *Error) Line() int {
+	return e.token.Line
+}
+
+// Message is the error without its location
+func (e *Error) Message() string {
+	return e.message
+}
+
 func NewRuntimeError(token *lexer.Token, message string) *Error {
 	return &Error{token, message}
 }
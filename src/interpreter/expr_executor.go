@@ -1,6 +1,12 @@
 package interpreter
 
 import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
 	l "github.com/debugg-er/lox/src/lexer"
 	"github.com/debugg-er/lox/src/parser"
 )
@@ -17,35 +23,73 @@ func (i *Interpreter) Evaluate(e parser.Expr) (*Value, error) {
 		return i.evaluateVariable(e)
 	case *parser.AssignExpr:
 		return i.evaluateAssign(e)
+	case *parser.SetExpr:
+		return i.evaluateSet(e)
+	case *parser.DestructureExpr:
+		return i.evaluateDestructure(e)
+	case *parser.IncrementExpr:
+		return i.evaluateIncrement(e)
 	case *parser.FuncExpr:
 		return i.evaluateFunc(e)
 	case *parser.CallExpr:
 		return i.evaluateCall(e)
+	case *parser.ListExpr:
+		return i.evaluateList(e)
+	case *parser.IndexExpr:
+		return i.evaluateIndex(e)
+	case *parser.SliceExpr:
+		return i.evaluateSlice(e)
+	case *parser.GetExpr:
+		return i.evaluateGet(e)
+	case *parser.OptionalChainExpr:
+		return i.evaluateOptionalChain(e)
+	case *parser.ConditionalExpr:
+		return i.evaluateConditional(e)
+	case *parser.MapExpr:
+		return i.evaluateMap(e)
+	case *parser.RangeExpr:
+		return i.evaluateRange(e)
+	case *parser.InterpolationExpr:
+		return i.evaluateInterpolation(e)
 	}
 
 	return nil, nil
 }
 
 func (i *Interpreter) evaluatePrimary(e *parser.PrimaryExpr) (*Value, error) {
-	return NewValue(e.Value.Value), nil
+	return literalValue(e.Value), nil
+}
+
+// literalValue returns the value of a literal token
+func literalValue(token *l.Token) *Value {
+	if token.Type == l.DECIMAL {
+		d, _ := parseDecimal(token.Value.(string))
+		return NewValue(d)
+	}
+	return NewValue(token.Value)
 }
 
 func (i *Interpreter) evaluateUnary(e *parser.UnaryExpr) (*Value, error) {
-	preValue, err := i.Evaluate(e)
+	preValue, err := i.Evaluate(e.Operand)
 	if err != nil {
 		return nil, err
 	}
 	switch e.Operator.Type {
 	case l.MINUS:
 		if isNumericOperand(*preValue) {
-			return NewValue(-toNumber(*preValue)), nil
+			return arithmetic(l.MINUS, e.Operator, NewValue(int64(0)), preValue)
 		} else {
 			return nil, NewRuntimeError(e.Operator, "Bad datatype for unary operator")
 		}
+	case l.TILDE:
+		if !isIntegerOperand(*preValue) {
+			return nil, NewRuntimeError(e.Operator, "Operand must be an integer")
+		}
+		return NewValue(new(big.Int).Not(toBigInt(*preValue))), nil
 	case l.BANG:
 		return NewValue(!isTruthy(*preValue)), nil
 	default:
-		panic("Language fatal: Undefined unary operator")
+		return nil, NewRuntimeError(e.Operator, "Undefined unary operator")
 	}
 }
 
@@ -59,11 +103,23 @@ func (i *Interpreter) evaluateBinary(e *parser.BinaryExpr) (*Value, error) {
 		if isTruthy(*left) && e.Operator.Type == l.OR {
 			return NewValue(true), nil
 		}
+		if !isTruthy(*left) && e.Operator.Type == l.AND {
+			return NewValue(false), nil
+		}
 		right, err := i.Evaluate(e.Right)
 		if err != nil {
 			return nil, err
 		}
 		return NewValue(isTruthy(*right)), nil
+	case l.QUESTION_QUESTION:
+		left, err := i.Evaluate(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		if left.DataType != NULL_DT {
+			return left, nil
+		}
+		return i.Evaluate(e.Right)
 	}
 
 	left, err := i.Evaluate(e.Left)
@@ -74,78 +130,90 @@ func (i *Interpreter) evaluateBinary(e *parser.BinaryExpr) (*Value, error) {
 	if err != nil {
 		return nil, err
 	}
+	return binaryOperation(e.Operator.Type, e.Operator, left, right)
+}
 
-	switch e.Operator.Type {
+// binaryOperation applies a non logical binary operator, errors being
+// reported at `token`
+func binaryOperation(operator l.TokenType, token *l.Token, left *Value, right *Value) (*Value, error) {
+	switch operator {
 	case l.PLUS:
 		if !isNumericOperand(*left, *right) {
 			return NewValue(left.Stringify() + right.Stringify()), nil
 		}
-		return NewValue(toNumber(*left) + toNumber(*right)), nil
-	case l.MINUS:
-		if !isNumericOperand(*left, *right) {
-			return nil, NewRuntimeError(e.Operator, "Operands must be a number")
-		}
-		return NewValue(toNumber(*left) - toNumber(*right)), nil
-	case l.STAR:
-		if !isNumericOperand(*left, *right) {
-			return nil, NewRuntimeError(e.Operator, "Operands must be a number")
-		}
-		return NewValue(toNumber(*left) * toNumber(*right)), nil
-	case l.SLASH:
+		return arithmetic(operator, token, left, right)
+	case l.MINUS, l.STAR, l.SLASH, l.PERCENT, l.STAR_STAR:
 		if !isNumericOperand(*left, *right) {
-			return nil, NewRuntimeError(e.Operator, "Operands must be a number")
+			return nil, NewRuntimeError(token, "Operands must be a number")
 		}
-		if toNumber(*right) == 0 {
-			return nil, NewRuntimeError(e.Operator, "Division by zero")
-		}
-		return NewValue(toNumber(*left) / toNumber(*right)), nil
+		return arithmetic(operator, token, left, right)
+	case l.AMPERSAND, l.PIPE, l.CARET, l.LESS_LESS, l.GREATER_GREATER:
+		return bitwise(operator, token, left, right)
 	case l.EQUAL_EQUAL:
-		return NewValue(left.DataType == right.DataType && left.Data == right.Data), nil
+		return NewValue(left.Equals(*right)), nil
 	case l.BANG_EQUAL:
-		return NewValue(!(left.DataType == right.DataType && left.Data == right.Data)), nil
+		return NewValue(!left.Equals(*right)), nil
 	case l.GREATER_EQUAL:
 		if left.DataType == STRING_DT && right.DataType == STRING_DT {
 			return NewValue(left.Data.(string) >= right.Data.(string)), nil
 		}
 		if isNumericOperand(*left, *right) {
-			return NewValue(toNumber(*left) >= toNumber(*right)), nil
+			order, ok := compareNumbers(*left, *right)
+			return NewValue(ok && order >= 0), nil
 		}
-		return nil, NewRuntimeError(e.Operator, "Incompatible operands")
+		return nil, NewRuntimeError(token, "Incompatible operands")
 	case l.GREATER:
 		if left.DataType == STRING_DT && right.DataType == STRING_DT {
 			return NewValue(left.Data.(string) > right.Data.(string)), nil
 		}
 		if isNumericOperand(*left, *right) {
-			return NewValue(toNumber(*left) > toNumber(*right)), nil
+			order, ok := compareNumbers(*left, *right)
+			return NewValue(ok && order > 0), nil
 		}
-		return nil, NewRuntimeError(e.Operator, "Incompatible operands")
+		return nil, NewRuntimeError(token, "Incompatible operands")
 	case l.LESS_EQUAL:
 		if left.DataType == STRING_DT && right.DataType == STRING_DT {
 			return NewValue(left.Data.(string) <= right.Data.(string)), nil
 		}
 		if isNumericOperand(*left, *right) {
-			return NewValue(toNumber(*left) <= toNumber(*right)), nil
+			order, ok := compareNumbers(*left, *right)
+			return NewValue(ok && order <= 0), nil
 		}
-		return nil, NewRuntimeError(e.Operator, "Incompatible operands")
+		return nil, NewRuntimeError(token, "Incompatible operands")
 	case l.LESS:
 		if left.DataType == STRING_DT && right.DataType == STRING_DT {
 			return NewValue(left.Data.(string) < right.Data.(string)), nil
 		}
 		if isNumericOperand(*left, *right) {
-			return NewValue(toNumber(*left) < toNumber(*right)), nil
+			order, ok := compareNumbers(*left, *right)
+			return NewValue(ok && order < 0), nil
 		}
-		return nil, NewRuntimeError(e.Operator, "Incompatible operands")
+		return nil, NewRuntimeError(token, "Incompatible operands")
 	default:
-		panic("Language fatal: Undefined binary operator")
+		return nil, NewRuntimeError(token, "Undefined binary operator")
 	}
 
 }
 
+func (i *Interpreter) evaluateConditional(e *parser.ConditionalExpr) (*Value, error) {
+	condition, err := i.Evaluate(e.Condition)
+	if err != nil {
+		return nil, err
+	}
+	if isTruthy(*condition) {
+		return i.Evaluate(e.Then)
+	}
+	return i.Evaluate(e.Else)
+}
+
 func (i *Interpreter) evaluateVariable(e *parser.VariableExpr) (*Value, error) {
 	return i.env.get(e.Name)
 }
 
 func (i *Interpreter) evaluateAssign(e *parser.AssignExpr) (*Value, error) {
+	if e.Operator.Type != l.EQUAL {
+		return i.assign(&parser.VariableExpr{Name: e.Name}, e.Operator, e.Value)
+	}
 	value, err := i.Evaluate(e.Value)
 	if err != nil {
 		return nil, err
@@ -157,81 +225,494 @@ func (i *Interpreter) evaluateAssign(e *parser.AssignExpr) (*Value, error) {
 	return value, nil
 }
 
-func (i *Interpreter) evaluateFunc(e *parser.FuncExpr) (*Value, error) {
-	value := &Value{
-		DataType: FUNCTION_DT,
-		Data:     e.FuncStmt,
+func (i *Interpreter) evaluateSet(e *parser.SetExpr) (*Value, error) {
+	return i.assign(e.Target, e.Operator, e.Value)
+}
+
+func (i *Interpreter) evaluateIncrement(e *parser.IncrementExpr) (*Value, error) {
+	ref, err := i.reference(e.Target)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := ref.get()
+	if err != nil {
+		return nil, err
 	}
-	if e.FuncStmt.Name != nil {
-		i.env.define(e.FuncStmt.Name, value)
+	if !isNumber(*previous) {
+		return nil, NewRuntimeError(e.Operator, "Operand must be a number")
 	}
-	return value, nil
+	operator := l.TokenType(l.PLUS)
+	if e.Operator.Type == l.MINUS_MINUS {
+		operator = l.MINUS
+	}
+	value, err := arithmetic(operator, e.Operator, previous, NewValue(int64(1)))
+	if err != nil {
+		return nil, err
+	}
+	if err := ref.set(value); err != nil {
+		return nil, err
+	}
+	if e.Prefix {
+		return value, nil
+	}
+	return previous, nil
+}
+
+func (i *Interpreter) evaluateFunc(e *parser.FuncExpr) (*Value, error) {
+	return NewValue(&Function{e.FuncStmt, i.env, i.owner}), nil
 }
 
 func (i *Interpreter) evaluateCall(e *parser.CallExpr) (*Value, error) {
-	value, err := i.Evaluate(e.Callee)
+	callee, arguments, named, err := i.evaluateCallParts(e)
 	if err != nil {
 		return nil, err
 	}
-	if value.DataType != FUNCTION_DT {
-		// Must fix
-		return nil, NewRuntimeError(&l.Token{Type: l.FUN, Line: 0}, "Expected function call.")
+	return i.call(callee, arguments, named, e.Paren)
+}
+
+// evaluateCallParts evaluates the callee and the arguments of a call
+func (i *Interpreter) evaluateCallParts(e *parser.CallExpr) (*Value, []*Value, map[string]*Value, error) {
+	callee, err := i.Evaluate(e.Callee)
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	funcStmt := value.Data.(*parser.FuncStmt)
-	if len(e.Arguments) < len(funcStmt.Parameters) {
-		return nil, NewRuntimeError(funcStmt.Parameters[0], "Too few arguments.")
+	// The arity is checked once the spread arguments are expanded
+	arguments, err := i.evaluateElements(e.Arguments)
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	if len(e.Arguments) > len(funcStmt.Parameters) {
-		return nil, NewRuntimeError(funcStmt.Parameters[0], "Too many arguments.")
+	named := make(map[string]*Value, len(e.NamedArguments))
+	for _, argument := range e.NamedArguments {
+		argumentVal, err := i.Evaluate(argument.Value)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		named[argument.Name.Value.(string)] = argumentVal
 	}
+	return callee, arguments, named, nil
+}
 
-	oldEnv := i.env
-	i.env = NewEnvironment(i.env)
-	i.env.returableTarget = funcStmt
+// Call invokes a function value with already evaluated arguments, `token`
+// is the call site used to report runtime errors
+func (i *Interpreter) Call(callee *Value, arguments []*Value, token *l.Token) (*Value, error) {
+	return i.call(callee, arguments, nil, token)
+}
+
+func (i *Interpreter) call(callee *Value, arguments []*Value, named map[string]*Value, token *l.Token) (*Value, error) {
+	switch function := callee.Data.(type) {
+	case *NativeFunction:
+		if len(named) != 0 {
+			return nil, NewRuntimeError(token, "Native function '"+function.Name+"' doesn't accept named arguments.")
+		}
+		if function.Arity >= 0 && len(arguments) != function.Arity {
+			return nil, NewRuntimeError(token, fmt.Sprintf("Expected %d arguments but got %d.", function.Arity, len(arguments)))
+		}
+		return function.Call(i, token, arguments)
+	case *Function:
+		return i.callFunction(function, arguments, named, token)
+	default:
+		return nil, NewRuntimeError(token, "Expected function call.")
+	}
+}
+
+func (i *Interpreter) callFunction(function *Function, arguments []*Value, named map[string]*Value, token *l.Token) (*Value, error) {
+	funcStmt := function.Declaration
+	if err := checkArity(funcStmt, len(arguments), len(named) != 0, token); err != nil {
+		return nil, err
+	}
+	if i.callDepth == maxCallDepth {
+		return nil, NewRuntimeError(token, "Stack overflow.")
+	}
+	i.callDepth++
+	defer func() { i.callDepth-- }()
+
+	// The loops of the caller can't be the target of a break in the callee
+	oldEnv, oldLoops, oldOwner := i.env, i.loops, i.owner
+	i.env, i.loops, i.owner = NewEnvironment(function.Closure), 0, function.owner
 	defer func() {
-		i.env = oldEnv
-		funcStmt.SetIsReturned(false)
+		i.env, i.loops, i.owner = oldEnv, oldLoops, oldOwner
+		i.jump, i.returnValue = noJump, nil
 	}()
 
-	for j, paramName := range funcStmt.Parameters {
-		argumentVal, err := i.Evaluate(e.Arguments[j])
+	if funcStmt.IsGenerator {
+		generator := i.newGenerator(function, i.env)
+		// Functions declared by default values belong to the generator
+		i.owner = generator
+		if err := i.bindArguments(funcStmt, arguments, named, token); err != nil {
+			return nil, err
+		}
+		return NewValue(generator), nil
+	}
+	if err := i.bindArguments(funcStmt, arguments, named, token); err != nil {
+		return nil, err
+	}
+
+	if err := i.Execute(funcStmt.Body); err != nil {
+		return nil, err
+	}
+	if i.jump == returnJump {
+		return i.returnValue, nil
+	}
+	return NewValue(nil), nil
+}
+
+// checkArity reports a call with a wrong number of positional arguments.
+// Missing arguments may still be given by name, they are reported while
+// binding them.
+func checkArity(funcStmt *parser.FuncStmt, count int, hasNamed bool, token *l.Token) error {
+	required, positional, rest := 0, 0, false
+	for _, parameter := range funcStmt.Parameters {
+		switch {
+		case parameter.Rest:
+			rest = true
+		case parameter.Default == nil:
+			required++
+			positional++
+		default:
+			positional++
+		}
+	}
+	if (count >= required || hasNamed) && (count <= positional || rest) {
+		return nil
+	}
+	var message string
+	switch {
+	case rest:
+		message = fmt.Sprintf("Expected at least %d arguments but got %d.", required, count)
+	case required == positional:
+		message = fmt.Sprintf("Expected %d arguments but got %d.", required, count)
+	default:
+		message = fmt.Sprintf("Expected %d to %d arguments but got %d.", required, positional, count)
+	}
+	return NewRuntimeError(token, message)
+}
+
+// bindArguments defines the parameters in the function environment.
+// Default values are evaluated there, after the preceding parameters, so a
+// default may refer to them.
+func (i *Interpreter) bindArguments(funcStmt *parser.FuncStmt, arguments []*Value, named map[string]*Value, token *l.Token) error {
+	parameters := make(map[string]*parser.Parameter, len(funcStmt.Parameters))
+	for _, parameter := range funcStmt.Parameters {
+		parameters[parameter.Name.Value.(string)] = parameter
+	}
+	for name := range named {
+		parameter := parameters[name]
+		if parameter == nil {
+			return NewRuntimeError(token, "Unknown parameter '"+name+"'.")
+		}
+		if parameter.Rest {
+			return NewRuntimeError(token, "Rest parameter '"+name+"' can't be passed by name.")
+		}
+	}
+
+	for j, parameter := range funcStmt.Parameters {
+		name := parameter.Name.Value.(string)
+		if parameter.Rest {
+			rest := make([]*Value, 0)
+			if j < len(arguments) {
+				rest = append(rest, arguments[j:]...)
+			}
+			i.env.define(parameter.Name, NewValue(NewList(rest)))
+			continue
+		}
+
+		value, isNamed := named[name]
+		switch {
+		case j < len(arguments) && isNamed:
+			return NewRuntimeError(token, "Argument for parameter '"+name+"' given more than once.")
+		case j < len(arguments):
+			value = arguments[j]
+		case isNamed:
+		case parameter.Default != nil:
+			var err error
+			if value, err = i.Evaluate(parameter.Default); err != nil {
+				return err
+			}
+		default:
+			return NewRuntimeError(token, "Missing argument for parameter '"+name+"'.")
+		}
+		i.env.define(parameter.Name, value)
+	}
+	return nil
+}
+
+func (i *Interpreter) evaluateList(e *parser.ListExpr) (*Value, error) {
+	elements, err := i.evaluateElements(e.Elements)
+	if err != nil {
+		return nil, err
+	}
+	return NewValue(NewList(elements)), nil
+}
+
+// evaluateElements evaluates the elements of a list or the arguments of a
+// call, expanding the spread ones
+func (i *Interpreter) evaluateElements(exprs []parser.Expr) ([]*Value, error) {
+	elements := make([]*Value, 0, len(exprs))
+	for _, expr := range exprs {
+		spread, ok := expr.(*parser.SpreadExpr)
+		if !ok {
+			value, err := i.Evaluate(expr)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, value)
+			continue
+		}
+		iterable, err := i.Evaluate(spread.Value)
+		if err != nil {
+			return nil, err
+		}
+		next, err := i.iterate(iterable, spread.Ellipsis)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			value, ok, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			elements = append(elements, value)
+		}
+	}
+	return elements, nil
+}
+
+// evaluateMap evaluates the entries of a map literal in order, a later
+// entry replacing an earlier one of the same key
+func (i *Interpreter) evaluateMap(e *parser.MapExpr) (*Value, error) {
+	m := NewMap()
+	for j, keyExpr := range e.Keys {
+		if spread, ok := keyExpr.(*parser.SpreadExpr); ok {
+			value, err := i.Evaluate(spread.Value)
+			if err != nil {
+				return nil, err
+			}
+			other, ok := value.Data.(*Map)
+			if !ok {
+				return nil, NewRuntimeError(spread.Ellipsis, "Only maps can be spread in a map, got "+value.Repr()+".")
+			}
+			for _, key := range other.Keys() {
+				entry, _ := other.Get(key)
+				m.Set(key, entry)
+			}
+			continue
+		}
+		key, err := i.Evaluate(keyExpr)
+		if err != nil {
+			return nil, err
+		}
+		value, err := i.Evaluate(e.Values[j])
+		if err != nil {
+			return nil, err
+		}
+		m.Set(key, value)
+	}
+	return NewValue(m), nil
+}
+
+func (i *Interpreter) evaluateRange(e *parser.RangeExpr) (*Value, error) {
+	bounds := [3]float64{0, 0, 1}
+	integers := [3]int64{0, 0, 1}
+	integer := true
+	for j, bound := range []parser.Expr{e.Start, e.End, e.Step} {
+		if bound == nil {
+			continue
+		}
+		value, err := i.Evaluate(bound)
 		if err != nil {
 			return nil, err
 		}
-		i.env.define(paramName, argumentVal)
+		if !isNumber(*value) {
+			return nil, NewRuntimeError(e.Operator, "Range bounds and step must be numbers.")
+		}
+		if value.DataType == BIG_INTEGER_DT {
+			return nil, NewRuntimeError(e.Operator, "Range bounds and step must fit in 64 bits.")
+		}
+		bounds[j] = toNumber(*value)
+		if value.DataType == INTEGER_DT {
+			integers[j] = value.Data.(int64)
+		} else {
+			integer = false
+		}
+	}
+	if bounds[2] == 0 {
+		return nil, NewRuntimeError(e.Operator, "Range step can't be zero.")
+	}
+	r := &Range{Inclusive: e.Operator.Type == l.DOT_DOT_EQUAL, Integer: integer}
+	if integer {
+		r.IntStart, r.IntEnd, r.IntStep = integers[0], integers[1], integers[2]
+	} else {
+		r.Start, r.End, r.Step = bounds[0], bounds[1], bounds[2]
 	}
+	return NewValue(r), nil
+}
 
-	if err = i.Execute(funcStmt); err != nil {
+func (i *Interpreter) evaluateIndex(e *parser.IndexExpr) (*Value, error) {
+	object, err := i.Evaluate(e.Object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.Evaluate(e.Index)
+	if err != nil {
 		return nil, err
 	}
-	if i.env.returnValue == nil {
+	return indexValue(object, index, e.Bracket)
+}
+
+func indexValue(object *Value, index *Value, bracket *l.Token) (*Value, error) {
+	switch object := object.Data.(type) {
+	case string:
+		characters := []rune(object)
+		position, err := toIndex(index, len(characters), bracket)
+		if err != nil {
+			return nil, err
+		}
+		return NewValue(string(characters[position])), nil
+	case *List:
+		position, err := toIndex(index, object.Len(), bracket)
+		if err != nil {
+			return nil, err
+		}
+		return object.Get(position), nil
+	case *Map:
+		// A missing key reads as nil
+		if value, ok := object.Get(index); ok {
+			return value, nil
+		}
 		return NewValue(nil), nil
+	default:
+		return nil, NewRuntimeError(bracket, "Only strings, lists and maps can be indexed.")
 	}
-	return i.env.returnValue, nil
 }
 
-// func (e *CallExpr) Call(env *Environment) (*Value, error) {
+func (i *Interpreter) evaluateSlice(e *parser.SliceExpr) (*Value, error) {
+	object, err := i.Evaluate(e.Object)
+	if err != nil {
+		return nil, err
+	}
+	var bounds [2]*Value
+	for j, bound := range []parser.Expr{e.Start, e.End} {
+		if bound == nil {
+			continue
+		}
+		if bounds[j], err = i.Evaluate(bound); err != nil {
+			return nil, err
+		}
+	}
 
-// }
+	var length int
+	switch object := object.Data.(type) {
+	case string:
+		length = utf8.RuneCountInString(object)
+	case *List:
+		length = object.Len()
+	default:
+		return nil, NewRuntimeError(e.Bracket, "Only strings and lists can be sliced.")
+	}
+	start, err := toSliceBound(bounds[0], length, 0, e.Bracket)
+	if err != nil {
+		return nil, err
+	}
+	end, err := toSliceBound(bounds[1], length, length, e.Bracket)
+	if err != nil {
+		return nil, err
+	}
+	if start > end {
+		start = end
+	}
+
+	switch object := object.Data.(type) {
+	case string:
+		return NewValue(string([]rune(object)[start:end])), nil
+	default:
+		return NewValue(NewList(object.(*List).Slice(start, end))), nil
+	}
+}
+
+func (i *Interpreter) evaluateInterpolation(e *parser.InterpolationExpr) (*Value, error) {
+	var str strings.Builder
+	for _, part := range e.Parts {
+		value, err := i.Evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		str.WriteString(value.Stringify())
+	}
+	return NewValue(str.String()), nil
+}
+
+func (i *Interpreter) evaluateGet(e *parser.GetExpr) (*Value, error) {
+	object, err := i.Evaluate(e.Object)
+	if err != nil {
+		return nil, err
+	}
+	if e.Optional && object.DataType == NULL_DT {
+		return nil, errNilChain
+	}
+	return property(object, e.Name)
+}
+
+// errNilChain unwinds an optional chain from the `?.` having found nil
+var errNilChain = errors.New("nil optional chain")
+
+func (i *Interpreter) evaluateOptionalChain(e *parser.OptionalChainExpr) (*Value, error) {
+	value, err := i.Evaluate(e.Chain)
+	if err == errNilChain {
+		return NewValue(nil), nil
+	}
+	return value, err
+}
+
+func property(object *Value, name *l.Token) (*Value, error) {
+	switch object := object.Data.(type) {
+	case string:
+		return stringProperty(object, name)
+	case *List:
+		return listProperty(object, name)
+	case *Map:
+		return mapProperty(object, name)
+	case *Generator:
+		return generatorProperty(object, name)
+	case *Channel:
+		return channelProperty(object, name)
+	case *WaitGroup:
+		return waitGroupProperty(object, name)
+	case *Decimal:
+		return decimalProperty(object, name)
+	default:
+		return nil, NewRuntimeError(name, "Only strings, lists, maps, decimals, generators, channels and wait groups have properties.")
+	}
+}
 
 func isTruthy(value Value) bool {
 	switch value.DataType {
 	case NUMBER_DT:
-		return value.Data != 0
+		return value.Data.(float64) != 0
+	case INTEGER_DT:
+		return value.Data.(int64) != 0
+	case DECIMAL_DT:
+		return value.Data.(*Decimal).Sign() != 0
 	case STRING_DT:
 		return value.Data != ""
 	case BOOLEAN_DT:
 		return value.Data.(bool)
+	case LIST_DT:
+		return value.Data.(*List).Len() != 0
+	case MAP_DT:
+		return value.Data.(*Map).Len() != 0
 	case NULL_DT:
 		return false
 	default:
-		panic("Language fatal: Undefined datatype")
+		return true
 	}
 }
 
 func isNumericOperand(values ...Value) bool {
 	for _, value := range values {
-		if value.DataType != BOOLEAN_DT && value.DataType != NUMBER_DT {
+		if value.DataType != BOOLEAN_DT && !isNumber(value) {
 			return false
 		}
 	}
@@ -248,6 +729,13 @@ func toNumber(value Value) float64 {
 		}
 	case NUMBER_DT:
 		return value.Data.(float64)
+	case INTEGER_DT:
+		return float64(value.Data.(int64))
+	case BIG_INTEGER_DT:
+		number, _ := new(big.Float).SetInt(value.Data.(*big.Int)).Float64()
+		return number
+	case DECIMAL_DT:
+		return value.Data.(*Decimal).Float64()
 	default:
 		panic("Language Fatal: Can't parse value to number")
 	}
@@ -0,0 +1,188 @@
+package interpreter
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"weak"
+
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+// errGeneratorClosed unwinds the body of a generator which was garbage
+// collected while suspended
+var errGeneratorClosed = errors.New("generator closed")
+
+// Generator is the suspended execution of a generator function. Its body
+// runs in a goroutine of its own, started by the first `next()`, which
+// hands the control back and forth with the caller so that only one of
+// them runs at a time.
+type Generator struct {
+	Name    string
+	body    *parser.BlockStmt
+	child   *Interpreter // Released once the goroutine is started
+	closure *Environment // Environment of the declaration, see Environment.outer
+	owner   *Generator   // Generator whose body declares the generator function
+	mutex   sync.Mutex   // Tasks sharing a generator resume it in turn
+	started bool
+	done    bool
+	closer  *generatorCloser
+	*generatorChannels
+}
+
+// generatorChannels are shared by a generator and its goroutine. The
+// goroutine only references the Generator while it runs, a suspended
+// generator can then be collected when unreachable.
+type generatorChannels struct {
+	resume  chan *Generator
+	results chan generatorResult
+	cancel  chan struct{}
+}
+
+// generatorCloser closes the goroutine of a generator in its finalizer. A
+// finalizer never runs on an object of a cycle, which the Generator may be
+// part of through its closure, so it's set on the closer referenced only
+// by the Generator.
+type generatorCloser struct {
+	cancel chan struct{}
+}
+
+type generatorResult struct {
+	value *Value
+	done  bool
+	err   error
+}
+
+// newGenerator creates the generator of a call whose arguments are bound
+// in `env`, the environment of the call. Its link to the closure becomes
+// weak so the goroutine doesn't keep the closure alive.
+func (i *Interpreter) newGenerator(function *Function, env *Environment) *Generator {
+	funcStmt := function.Declaration
+	channels := &generatorChannels{
+		resume:  make(chan *Generator),
+		results: make(chan generatorResult),
+		cancel:  make(chan struct{}),
+	}
+	generator := &Generator{
+		body:              funcStmt.Body,
+		child:             i.fork(),
+		closure:           function.Closure,
+		owner:             function.owner,
+		closer:            &generatorCloser{channels.cancel},
+		generatorChannels: channels,
+	}
+	env.outer, env.enclosing = weak.Make(env.enclosing), nil
+	generator.child.env = env
+	generator.child.callDepth = i.callDepth
+	generator.child.generator = channels
+	generator.child.owner = nil
+	if funcStmt.Name != nil {
+		generator.Name = funcStmt.Name.Value.(string)
+	}
+	runtime.SetFinalizer(generator.closer, func(c *generatorCloser) {
+		close(c.cancel)
+	})
+	return generator
+}
+
+// next resumes the generator until its next yield, `ok` is false once the
+// generator is exhausted
+func (g *Generator) next() (value *Value, ok bool, err error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.done {
+		return nil, false, nil
+	}
+	if g.started {
+		g.resume <- g
+	} else {
+		g.started = true
+		g.child.owner = g
+		go runGenerator(g.child, g.body, g.generatorChannels)
+		g.child, g.body = nil, nil
+	}
+	result := <-g.results
+	if result.done || result.err != nil {
+		g.done = true
+		return nil, false, result.err
+	}
+	return result.value, true, nil
+}
+
+// runGenerator executes the body of a generator, it must not reference the
+// Generator so the finalizer can run while the body is suspended
+func runGenerator(child *Interpreter, body *parser.BlockStmt, channels *generatorChannels) {
+	err := child.Execute(body)
+	if err == errGeneratorClosed {
+		return
+	}
+	select {
+	case channels.results <- generatorResult{done: true, err: err}:
+	case <-channels.cancel:
+	}
+}
+
+// executeYieldStmt hands the yielded value to the caller of `next()` and
+// suspends the generator until it's resumed
+func (i *Interpreter) executeYieldStmt(t *parser.YieldStmt) error {
+	value := NewValue(nil)
+	if t.Expr != nil {
+		var err error
+		if value, err = i.Evaluate(t.Expr); err != nil {
+			return err
+		}
+	}
+	if i.generator == nil {
+		return NewRuntimeError(t.Token, "Can't yield outside of a generator.")
+	}
+	// The suspended goroutine must not reference the generator
+	i.owner = nil
+	i.generator.results <- generatorResult{value: value}
+	select {
+	case i.owner = <-i.generator.resume:
+		return nil
+	case <-i.generator.cancel:
+		return errGeneratorClosed
+	}
+}
+
+var generatorMethods map[string]func(g *Generator) (*Value, error)
+
+// Like listMethods, resuming a generator calls back into the interpreter
+func init() {
+	generatorMethods = map[string]func(g *Generator) (*Value, error){
+		"next": generatorNext,
+	}
+}
+
+// generatorProperty returns the method `name` of a generator, bound to it
+func generatorProperty(g *Generator, name *l.Token) (*Value, error) {
+	method, ok := generatorMethods[name.Value.(string)]
+	if !ok {
+		return nil, NewRuntimeError(name, "Undefined property '"+name.Value.(string)+"' of generator.")
+	}
+	return NewValue(&NativeFunction{
+		Name:  name.Value.(string),
+		Arity: 0,
+		Call: func(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error) {
+			return method(g)
+		},
+	}), nil
+}
+
+// next returns `{done: false, value: value}` for every yielded value, then
+// `{done: true, value: nil}`, the same results as an iterator object
+func generatorNext(g *Generator) (*Value, error) {
+	value, ok, err := g.next()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		value = NewValue(nil)
+	}
+	result := NewMap()
+	result.Set(NewValue("done"), NewValue(!ok))
+	result.Set(NewValue("value"), value)
+	return NewValue(result), nil
+}
@@ -1,22 +1,99 @@
 package interpreter
 
-import "github.com/debugg-er/lox/src/parser"
+import (
+	"io"
+	"os"
+	"sync"
+
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+// maxCallDepth bounds the recursion of Lox functions, which would
+// otherwise crash the host with a Go stack overflow
+const maxCallDepth = 4096
 
 type Interpreter struct {
-	env *Environment
+	env       *Environment
+	coverage  *Coverage
+	stdout    io.Writer
+	callDepth int
+	generator *generatorChannels // Set while running the body of a generator
+	owner     *Generator         // Generator whose body declares the running code
+	tasks     *tasks
+	steps     *int64 // Statements left to execute, shared by the tasks. Nil for no limit.
+
+	// Control flow state of the running function: the number of loops
+	// enclosing the current statement and the pending break, continue or
+	// return unwinding the statements up to its target. The target of a
+	// break or continue is the loop named by label, or the innermost loop
+	// when label is empty.
+	loops       int
+	jump        jump
+	label       string
+	returnValue *Value
 }
 
+type jump int
+
+const (
+	noJump jump = iota
+	breakJump
+	continueJump
+	returnJump
+)
+
 func NewInterpreter() *Interpreter {
-	return &Interpreter{
-		env: NewEnvironment(nil),
+	i := &Interpreter{
+		env:    NewEnvironment(nil),
+		stdout: &lockedWriter{w: os.Stdout},
+		tasks:  newTasks(),
 	}
+	i.defineNatives()
+	return i
 }
 
+// Run executes a program and waits for the tasks it spawned, the error of
+// a failed task is returned when the program itself succeeded
 func (i *Interpreter) Run(statements []parser.Stmt) error {
 	for _, stmt := range statements {
 		if err := i.Execute(stmt); err != nil {
 			return err
 		}
 	}
+	if err := i.tasks.wait(); err != nil {
+		// The next run starts over, like a new program
+		i.tasks = newTasks()
+		return err
+	}
 	return nil
 }
+
+// SetOutput redirects the output of print statements to `w`, tasks print
+// one line at a time
+func (i *Interpreter) SetOutput(w io.Writer) {
+	i.stdout = &lockedWriter{w: w}
+}
+
+type lockedWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.w.Write(p)
+}
+
+// SetCoverage makes the interpreter record executed statements and
+// branches into `coverage`, pass nil to disable instrumentation
+func (i *Interpreter) SetCoverage(coverage *Coverage) {
+	i.coverage = coverage
+}
+
+// DefineNative makes a Go function callable from the global scope
+func (i *Interpreter) DefineNative(native *NativeFunction) {
+	name := &l.Token{Type: l.IDENTIFIER, Value: native.Name}
+	i.env.define(name, &Value{FUNCTION_DT, native})
+}
@@ -0,0 +1,122 @@
+package interpreter
+
+import (
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+func FuzzInterpreter(f *testing.F) {
+	for _, seed := range []string{
+		"print -1 + 2 * 3;",
+		"print !\"\" and 1 or nil;",
+		"fun f(n) { if (n <= 1) return n; return f(n - 1) + 1; } print f(10);",
+		"fun f() { return f(); } f();",
+		"fun f() {} if (f) print !f;",
+		"var a; print a;",
+		"print -\"a\";",
+		"print 1 / 0;",
+		"1();",
+		"fun f(a) {} f();",
+		"while (true) {} for (;;) {}",
+		"loop { } do { } while (true);",
+		"fun f() { loop {} } spawn f(); channel().recv();",
+		"fun f(n) { f(n); f(n); } f(0);",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, source string) {
+		tokens, err := l.NewLexer().Parse(source)
+		if err != nil {
+			return
+		}
+		statements, errs := parser.NewParser().Parse(tokens)
+		if len(errs) != 0 {
+			return
+		}
+		i := NewInterpreter()
+		i.SetOutput(io.Discard)
+		// Programs may never terminate, which is not what this target
+		// checks
+		steps := int64(100000)
+		i.steps = &steps
+		i.Run(statements)
+	})
+}
+
+// Suspended generators which are no longer reachable must not leave their
+// goroutine behind
+func TestGeneratorGoroutineIsReleased(t *testing.T) {
+	checkGoroutinesReleased(t, "fun naturals() { var i = 0; while (true) { yield i; i = i + 1; } }\n"+
+		"fun first() { var g = naturals(); g.next(); return g.next(); }\n"+
+		"for (i in 0..100) first();")
+}
+
+// A generator function declared in a function is part of the environment
+// the generator closes over, which the goroutine must not keep alive
+func TestNestedGeneratorGoroutineIsReleased(t *testing.T) {
+	checkGoroutinesReleased(t, "fun first() { fun gen() { yield 1; yield 2; } var g = gen(); g.next(); }\n"+
+		"for (i in 0..100) first();\n"+
+		"fun counter(step) {\n"+
+		"  fun count(from) { var i = from; while (true) { yield i; i = i + step; } }\n"+
+		"  var evens = count(0); evens.next();\n"+
+		"  var odds = count(1); odds.next(); return odds.next().value;\n"+
+		"}\n"+
+		"for (i in 0..100) counter(2);")
+}
+
+// Functions declared in the body of a collected generator still see the
+// variables enclosing the generator
+func TestGeneratorClosureOutlivesGenerator(t *testing.T) {
+	source := "var results = [];\n" +
+		"fun make(base) { fun gen() { yield fun (x) { return base + x; }; yield nil; } return gen().next().value; }\n" +
+		"var add = make(10);\n"
+	statements := parse(t, source)
+	i := NewInterpreter()
+	if err := i.Run(statements); err != nil {
+		t.Fatal(err)
+	}
+	runtime.GC()
+	runtime.GC()
+	var output strings.Builder
+	i.SetOutput(&output)
+	if err := i.Run(parse(t, "print add(5);")); err != nil {
+		t.Fatal(err)
+	}
+	if output.String() != "15\n" {
+		t.Errorf("expected 15, got %q", output.String())
+	}
+}
+
+func checkGoroutinesReleased(t *testing.T, source string) {
+	statements := parse(t, source)
+	before := runtime.NumGoroutine()
+	i := NewInterpreter()
+	if err := i.Run(statements); err != nil {
+		t.Fatal(err)
+	}
+	for attempt := 0; attempt < 50 && runtime.NumGoroutine() > before; attempt++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if leaked := runtime.NumGoroutine() - before; leaked > 0 {
+		t.Fatalf("%d generator goroutines leaked", leaked)
+	}
+}
+
+func parse(t *testing.T, source string) []parser.Stmt {
+	tokens, err := l.NewLexer().Parse(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, errs := parser.NewParser().Parse(tokens)
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	return statements
+}
@@ -0,0 +1,162 @@
+package interpreter
+
+import (
+	"math"
+
+	l "github.com/debugg-er/lox/src/lexer"
+)
+
+// iterator returns the next value of an iteration, `ok` is false once the
+// iteration is over
+type iterator func() (value *Value, ok bool, err error)
+
+// Range is the sequence of numbers from Start to End by Step, End being
+// included only when Inclusive is set. When the bounds and the step are
+// integers, Integer is set and they are stored in the Int fields instead,
+// so ranges past 2^53 stay exact.
+type Range struct {
+	Start     float64
+	End       float64
+	Step      float64
+	IntStart  int64
+	IntEnd    int64
+	IntStep   int64
+	Inclusive bool
+	Integer   bool
+}
+
+// iterate returns an iterator over the values of a collection. Lists give
+// their elements, maps their keys and strings their characters. Ranges
+// give their numbers and generators their yielded values. Channels give
+// the values received until they are closed. A map with a `next` function
+// is an iterator object, its `next()` returns `{done: bool, value: value}`.
+func (i *Interpreter) iterate(iterable *Value, token *l.Token) (iterator, error) {
+	switch iterable := iterable.Data.(type) {
+	case *List:
+		index := 0
+		return func() (*Value, bool, error) {
+			if index >= iterable.Len() {
+				return nil, false, nil
+			}
+			index++
+			return iterable.Get(index - 1), true, nil
+		}, nil
+	case string:
+		return elements(stringCharacters(iterable)), nil
+	case *Map:
+		if next, ok := iterable.Get(NewValue("next")); ok && next.DataType == FUNCTION_DT {
+			return i.iterateObject(next, token), nil
+		}
+		keys := make([]*Value, iterable.Len())
+		copy(keys, iterable.Keys())
+		return elements(keys), nil
+	case *Generator:
+		return iterable.next, nil
+	case *Channel:
+		return func() (*Value, bool, error) {
+			return iterable.recv(i, token)
+		}, nil
+	case *Range:
+		if iterable.Integer {
+			return iterable.integers(), nil
+		}
+		count := 0.0
+		return func() (*Value, bool, error) {
+			// Computed from the start to not accumulate rounding errors
+			value := iterable.Start + count*iterable.Step
+			if !iterable.contains(value) {
+				return nil, false, nil
+			}
+			count++
+			return NewValue(value), true, nil
+		}, nil
+	default:
+		return nil, NewRuntimeError(token, "Can't iterate over "+NewValue(iterable).Repr()+".")
+	}
+}
+
+func (i *Interpreter) iterateObject(next *Value, token *l.Token) iterator {
+	return func() (*Value, bool, error) {
+		result, err := i.Call(next, []*Value{}, token)
+		if err != nil {
+			return nil, false, err
+		}
+		m, ok := result.Data.(*Map)
+		if !ok {
+			return nil, false, NewRuntimeError(token, "Iterator 'next' must return a map, got "+result.Repr()+".")
+		}
+		if done, ok := m.Get(NewValue("done")); ok && isTruthy(*done) {
+			return nil, false, nil
+		}
+		value, ok := m.Get(NewValue("value"))
+		if !ok {
+			value = NewValue(nil)
+		}
+		return value, true, nil
+	}
+}
+
+func elements(values []*Value) iterator {
+	index := 0
+	return func() (*Value, bool, error) {
+		if index >= len(values) {
+			return nil, false, nil
+		}
+		index++
+		return values[index-1], true, nil
+	}
+}
+
+func stringCharacters(s string) []*Value {
+	characters := make([]*Value, 0, len(s))
+	for _, character := range s {
+		characters = append(characters, NewValue(string(character)))
+	}
+	return characters
+}
+
+// integers iterates over an integer range. It stops instead of overflowing
+// once the next number is past the int64 limits, and so past the end.
+func (r *Range) integers() iterator {
+	value, done := r.IntStart, false
+	return func() (*Value, bool, error) {
+		if done || !r.containsInt(value) {
+			return nil, false, nil
+		}
+		current := value
+		value += r.IntStep
+		done = (r.IntStep > 0) != (value > current)
+		return NewValue(current), true, nil
+	}
+}
+
+func (r *Range) containsInt(value int64) bool {
+	switch {
+	case r.IntStep > 0 && r.Inclusive:
+		return value <= r.IntEnd
+	case r.IntStep > 0:
+		return value < r.IntEnd
+	case r.Inclusive:
+		return value >= r.IntEnd
+	default:
+		return value > r.IntEnd
+	}
+}
+
+// contains reports whether `value`, a number of the range sequence, hasn't
+// gone past the end of the range
+func (r *Range) contains(value float64) bool {
+	if math.IsNaN(value) {
+		return false
+	}
+	switch {
+	case r.Step > 0 && r.Inclusive:
+		return value <= r.End
+	case r.Step > 0:
+		return value < r.End
+	case r.Inclusive:
+		return value >= r.End
+	default:
+		return value > r.End
+	}
+}
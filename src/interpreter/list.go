@@ -0,0 +1,110 @@
+package interpreter
+
+import (
+	"sync"
+
+	l "github.com/debugg-er/lox/src/lexer"
+)
+
+// List is a sequence of values. A list may be used by many tasks at once,
+// its elements are read and assigned one at a time.
+type List struct {
+	mutex    sync.RWMutex
+	elements []*Value
+}
+
+func NewList(elements []*Value) *List {
+	return &List{elements: elements}
+}
+
+func (list *List) Get(position int) *Value {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	return list.elements[position]
+}
+
+func (list *List) Set(position int, value *Value) {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+	list.elements[position] = value
+}
+
+func (list *List) Len() int {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	return len(list.elements)
+}
+
+// Elements returns a copy of the elements of the list
+func (list *List) Elements() []*Value {
+	return list.Slice(0, list.Len())
+}
+
+// Slice returns a copy of the elements from `start` to `end` excluded
+func (list *List) Slice(start int, end int) []*Value {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	elements := make([]*Value, end-start)
+	copy(elements, list.elements[start:end])
+	return elements
+}
+
+type listMethod struct {
+	arity int
+	call  func(i *Interpreter, list *List, token *l.Token, arguments []*Value) (*Value, error)
+}
+
+var listMethods map[string]listMethod
+
+// Methods calling back into the interpreter can't be part of the map
+// initializer without an initialization cycle
+func init() {
+	listMethods = map[string]listMethod{
+		"map":    {1, listMap},
+		"filter": {1, listFilter},
+	}
+}
+
+// listProperty returns the method `name` of a list, bound to it
+func listProperty(list *List, name *l.Token) (*Value, error) {
+	method, ok := listMethods[name.Value.(string)]
+	if !ok {
+		return nil, NewRuntimeError(name, "Undefined property '"+name.Value.(string)+"' of list.")
+	}
+	return NewValue(&NativeFunction{
+		Name:  name.Value.(string),
+		Arity: method.arity,
+		Call: func(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error) {
+			return method.call(i, list, token, arguments)
+		},
+	}), nil
+}
+
+// map returns a new list made of the results of `callback(element)`
+func listMap(i *Interpreter, list *List, token *l.Token, arguments []*Value) (*Value, error) {
+	elements := make([]*Value, 0, list.Len())
+	for _, element := range list.Elements() {
+		result, err := i.Call(arguments[0], []*Value{element}, token)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, result)
+	}
+	return NewValue(NewList(elements)), nil
+}
+
+// filter returns a new list of the elements for which `callback(element)`
+// is truthy
+func listFilter(i *Interpreter, list *List, token *l.Token, arguments []*Value) (*Value, error) {
+	elements := make([]*Value, 0, list.Len())
+	for _, element := range list.Elements() {
+		keep, err := i.Call(arguments[0], []*Value{element}, token)
+		if err != nil {
+			return nil, err
+		}
+		if isTruthy(*keep) {
+			elements = append(elements, element)
+		}
+	}
+	return NewValue(NewList(elements)), nil
+}
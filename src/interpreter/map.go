@@ -0,0 +1,128 @@
+package interpreter
+
+import (
+	"math"
+	"math/big"
+	"sync"
+
+	l "github.com/debugg-er/lox/src/lexer"
+)
+
+// Map is a collection of key/value pairs kept in insertion order. Keys are
+// compared like `==` does, by value for primitives and by identity for
+// lists, maps and functions. A map may be used by many tasks at once.
+type Map struct {
+	mutex   sync.RWMutex
+	keys    []*Value
+	entries map[interface{}]*Value
+}
+
+func NewMap() *Map {
+	return &Map{keys: make([]*Value, 0), entries: make(map[interface{}]*Value)}
+}
+
+func (m *Map) Get(key *Value) (*Value, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	value, ok := m.entries[entryKey(key)]
+	return value, ok
+}
+
+func (m *Map) Set(key *Value, value *Value) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.entries[entryKey(key)]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[entryKey(key)] = value
+}
+
+// entryKey is the key of the entries of `key`. Numbers of equal values
+// have the same key: an integer, a float, or the string of a decimal or a
+// big integer having no equal int64 or float.
+func entryKey(key *Value) interface{} {
+	switch number := key.Data.(type) {
+	case float64:
+		if number == math.Trunc(number) && math.Abs(number) < math.MaxInt64 {
+			return int64(number)
+		}
+	case *big.Int:
+		return bigKey(number.String())
+	case *Decimal:
+		if number.IsInteger() {
+			return entryKey(NewValue(number.round(0, down).unscaled))
+		}
+		if float, exact := number.Rat().Float64(); exact {
+			return float
+		}
+		return bigKey(number.trim(0).String())
+	}
+	return key.Data
+}
+
+// bigKey is the entry key of a big integer or a decimal
+type bigKey string
+
+// Keys returns a copy of the keys of the map in insertion order
+func (m *Map) Keys() []*Value {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	keys := make([]*Value, len(m.keys))
+	copy(keys, m.keys)
+	return keys
+}
+
+func (m *Map) Len() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.keys)
+}
+
+type mapMethod struct {
+	arity int
+	call  func(m *Map, arguments []*Value) *Value
+}
+
+var mapMethods = map[string]mapMethod{
+	"keys":   {0, mapKeys},
+	"values": {0, mapValues},
+	"has":    {1, mapHas},
+}
+
+// mapProperty returns the entry of a map having the key `name`, or else
+// its method `name` bound to it
+func mapProperty(m *Map, name *l.Token) (*Value, error) {
+	if value, ok := m.Get(NewValue(name.Value.(string))); ok {
+		return value, nil
+	}
+	method, ok := mapMethods[name.Value.(string)]
+	if !ok {
+		return nil, NewRuntimeError(name, "Undefined property '"+name.Value.(string)+"' of map.")
+	}
+	return NewValue(&NativeFunction{
+		Name:  name.Value.(string),
+		Arity: method.arity,
+		Call: func(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error) {
+			return method.call(m, arguments), nil
+		},
+	}), nil
+}
+
+func mapKeys(m *Map, arguments []*Value) *Value {
+	return NewValue(NewList(m.Keys()))
+}
+
+func mapValues(m *Map, arguments []*Value) *Value {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	values := make([]*Value, 0, len(m.keys))
+	for _, key := range m.keys {
+		values = append(values, m.entries[entryKey(key)])
+	}
+	return NewValue(NewList(values))
+}
+
+func mapHas(m *Map, arguments []*Value) *Value {
+	_, ok := m.Get(arguments[0])
+	return NewValue(ok)
+}
@@ -0,0 +1,67 @@
+package interpreter
+
+import (
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+// binding is a name bound by a pattern, it's only defined once the whole
+// pattern matched
+type binding struct {
+	name  *l.Token
+	value *Value
+}
+
+// matchPattern reports whether `value` matches `pattern`, appending the
+// names it binds to `bindings`
+func matchPattern(pattern parser.Pattern, value *Value, bindings *[]binding) bool {
+	switch pattern := pattern.(type) {
+	case *parser.WildcardPattern:
+		return true
+	case *parser.BindingPattern:
+		*bindings = append(*bindings, binding{pattern.Name, value})
+		return true
+	case *parser.LiteralPattern:
+		return literalValue(pattern.Value.Value).Equals(*value)
+	case *parser.RangePattern:
+		if !isNumber(*value) {
+			return false
+		}
+		low, okLow := compareNumbers(*value, *literalValue(pattern.Low))
+		high, okHigh := compareNumbers(*value, *literalValue(pattern.High))
+		return okLow && okHigh && low >= 0 && (high < 0 || pattern.Inclusive && high == 0)
+	case *parser.ListPattern:
+		list, ok := value.Data.(*List)
+		if !ok {
+			return false
+		}
+		elements := list.Elements()
+		if len(elements) < len(pattern.Elements) || !pattern.Rest && len(elements) != len(pattern.Elements) {
+			return false
+		}
+		for j, element := range pattern.Elements {
+			if !matchPattern(element, elements[j], bindings) {
+				return false
+			}
+		}
+		if pattern.RestName != nil {
+			rest := elements[len(pattern.Elements):]
+			*bindings = append(*bindings, binding{pattern.RestName, NewValue(NewList(rest))})
+		}
+		return true
+	case *parser.MapPattern:
+		m, ok := value.Data.(*Map)
+		if !ok {
+			return false
+		}
+		for j, key := range pattern.Keys {
+			entry, ok := m.Get(NewValue(key.Value.Value))
+			if !ok || !matchPattern(pattern.Values[j], entry, bindings) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
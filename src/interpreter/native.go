@@ -0,0 +1,85 @@
+package interpreter
+
+import (
+	"math"
+	"math/big"
+	"unicode/utf8"
+
+	l "github.com/debugg-er/lox/src/lexer"
+)
+
+// defineNatives defines the global functions available to every program
+func (i *Interpreter) defineNatives() {
+	i.DefineNative(&NativeFunction{Name: "len", Arity: 1, Call: nativeLen})
+	i.DefineNative(&NativeFunction{Name: "channel", Arity: -1, Call: nativeChannel})
+	i.DefineNative(&NativeFunction{Name: "waitGroup", Arity: 0, Call: nativeWaitGroup})
+	i.DefineNative(&NativeFunction{Name: "decimal", Arity: 1, Call: nativeDecimal})
+}
+
+func nativeLen(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error) {
+	switch value := arguments[0].Data.(type) {
+	case string:
+		return NewValue(int64(utf8.RuneCountInString(value))), nil
+	case *List:
+		return NewValue(int64(value.Len())), nil
+	case *Map:
+		return NewValue(int64(value.Len())), nil
+	default:
+		return nil, NewRuntimeError(token, "Expected a string, a list or a map but got "+arguments[0].Repr()+".")
+	}
+}
+
+// toInteger converts an integer, or a float or a decimal without a
+// fractional part, to an int. A big integer is clamped to an int.
+func toInteger(value *Value, token *l.Token) (int, error) {
+	switch number := value.Data.(type) {
+	case int64:
+		return int(number), nil
+	case *big.Int:
+		if number.Sign() < 0 {
+			return math.MinInt, nil
+		}
+		return math.MaxInt, nil
+	case float64:
+		if number == math.Trunc(number) && !math.IsInf(number, 0) {
+			return int(number), nil
+		}
+	case *Decimal:
+		if number.IsInteger() {
+			return toInteger(NewValue(number.round(0, down).unscaled), token)
+		}
+	}
+	return 0, NewRuntimeError(token, "Expected an integer but got "+value.Repr()+".")
+}
+
+// toIndex converts an index of a sequence of `length` elements to a
+// position, negative indexes count from the end of the sequence
+func toIndex(value *Value, length int, token *l.Token) (int, error) {
+	index, err := toInteger(value, token)
+	if err != nil {
+		return 0, err
+	}
+	if index < 0 {
+		index += length
+	}
+	if index < 0 || index >= length {
+		return 0, NewRuntimeError(token, "Index out of range.")
+	}
+	return index, nil
+}
+
+// toSliceBound converts a slice bound to a position clamped to the
+// sequence, `bound` is nil when omitted and `fallback` is used instead
+func toSliceBound(bound *Value, length int, fallback int, token *l.Token) (int, error) {
+	if bound == nil {
+		return fallback, nil
+	}
+	index, err := toInteger(bound, token)
+	if err != nil {
+		return 0, err
+	}
+	if index < 0 {
+		index += length
+	}
+	return int(math.Max(0, math.Min(float64(index), float64(length)))), nil
+}
@@ -0,0 +1,264 @@
+package interpreter
+
+import (
+	"math"
+	"math/big"
+
+	l "github.com/debugg-er/lox/src/lexer"
+)
+
+// Numbers are integers, floats or decimals. An integer is the int64 data
+// of an INTEGER_DT value, or a *big.Int for a BIG_INTEGER_DT one when it
+// doesn't fit an int64. A float is the float64 data of a NUMBER_DT value
+// and a decimal the *Decimal of a DECIMAL_DT one. In arithmetic, booleans
+// are the integers 0 and 1.
+//
+// An operation on integers gives an integer, turning into a big integer
+// instead of overflowing. Integer division truncates toward zero and the
+// remainder has the sign of the dividend. An integer mixed with a float
+// turns into a float, and with a decimal into a decimal. Decimals can't be
+// mixed with floats.
+
+func isIntegerOperand(values ...Value) bool {
+	for _, value := range values {
+		if value.DataType != BOOLEAN_DT && value.DataType != INTEGER_DT && value.DataType != BIG_INTEGER_DT {
+			return false
+		}
+	}
+	return true
+}
+
+// toInt converts a boolean or an integer fitting an int64
+func toInt(value Value) int64 {
+	switch value.DataType {
+	case BOOLEAN_DT:
+		if value.Data.(bool) {
+			return 1
+		}
+		return 0
+	case INTEGER_DT:
+		return value.Data.(int64)
+	default:
+		panic("Language Fatal: Can't parse value to integer")
+	}
+}
+
+func toBigInt(value Value) *big.Int {
+	if value.DataType == BIG_INTEGER_DT {
+		return value.Data.(*big.Int)
+	}
+	return big.NewInt(toInt(value))
+}
+
+// arithmetic applies `+`, `-`, `*`, `/`, `%` or `**` to numeric operands
+func arithmetic(operator l.TokenType, token *l.Token, left *Value, right *Value) (*Value, error) {
+	if isIntegerOperand(*left, *right) {
+		return integerArithmetic(operator, token, left, right)
+	}
+	if left.DataType == DECIMAL_DT || right.DataType == DECIMAL_DT {
+		return decimalArithmetic(operator, token, left, right)
+	}
+	a, b := toNumber(*left), toNumber(*right)
+	switch operator {
+	case l.PLUS:
+		return NewValue(a + b), nil
+	case l.MINUS:
+		return NewValue(a - b), nil
+	case l.STAR:
+		return NewValue(a * b), nil
+	case l.SLASH:
+		if b == 0 {
+			return nil, NewRuntimeError(token, "Division by zero")
+		}
+		return NewValue(a / b), nil
+	case l.PERCENT:
+		if b == 0 {
+			return nil, NewRuntimeError(token, "Division by zero")
+		}
+		return NewValue(math.Mod(a, b)), nil
+	default:
+		return NewValue(math.Pow(a, b)), nil
+	}
+}
+
+func integerArithmetic(operator l.TokenType, token *l.Token, left *Value, right *Value) (*Value, error) {
+	if left.DataType != BIG_INTEGER_DT && right.DataType != BIG_INTEGER_DT {
+		if result, ok := smallArithmetic(operator, toInt(*left), toInt(*right)); ok {
+			return NewValue(result), nil
+		}
+	}
+	a, b := toBigInt(*left), toBigInt(*right)
+	result := new(big.Int)
+	switch operator {
+	case l.PLUS:
+		result.Add(a, b)
+	case l.MINUS:
+		result.Sub(a, b)
+	case l.STAR:
+		result.Mul(a, b)
+	case l.SLASH, l.PERCENT:
+		if b.Sign() == 0 {
+			return nil, NewRuntimeError(token, "Division by zero")
+		}
+		if operator == l.PERCENT {
+			result.Rem(a, b)
+		} else {
+			result.Quo(a, b)
+		}
+	default:
+		// A negative exponent gives a fraction
+		if b.Sign() < 0 {
+			return NewValue(math.Pow(toNumber(*left), toNumber(*right))), nil
+		}
+		if powerTooLarge(a, b) {
+			return nil, NewRuntimeError(token, "Exponent too large")
+		}
+		result.Exp(a, b, nil)
+	}
+	return NewValue(result), nil
+}
+
+// smallArithmetic computes an operation on int64 operands, it returns
+// false when the result doesn't fit an int64 or isn't an integer
+func smallArithmetic(operator l.TokenType, a int64, b int64) (int64, bool) {
+	switch operator {
+	case l.PLUS:
+		result := a + b
+		return result, (b <= 0 || result > a) && (b >= 0 || result < a)
+	case l.MINUS:
+		result := a - b
+		return result, (b >= 0 || result > a) && (b <= 0 || result < a)
+	case l.STAR:
+		return multiply(a, b)
+	case l.SLASH:
+		if b == 0 || (a == math.MinInt64 && b == -1) {
+			return 0, false
+		}
+		return a / b, true
+	case l.PERCENT:
+		if b == 0 {
+			return 0, false
+		}
+		return a % b, true
+	default:
+		if b < 0 {
+			return 0, false
+		}
+		result := int64(1)
+		for base, ok := a, true; b > 0; b >>= 1 {
+			if b&1 == 1 {
+				if result, ok = multiply(result, base); !ok {
+					return 0, false
+				}
+			}
+			if b > 1 {
+				if base, ok = multiply(base, base); !ok {
+					return 0, false
+				}
+			}
+		}
+		return result, true
+	}
+}
+
+// multiply returns a * b, or false on overflow
+func multiply(a int64, b int64) (int64, bool) {
+	product := a * b
+	if a != 0 && (product/a != b || (a == -1 && b == math.MinInt64)) {
+		return 0, false
+	}
+	return product, true
+}
+
+// maxShift bounds the count of a left shift and the bits of a power, a
+// larger one would exhaust the memory
+const maxShift = 1 << 24
+
+// powerTooLarge reports whether `base ** exponent` has more than maxShift
+// bits, `exponent` being positive. The power has at least
+// (base.BitLen() - 1) * exponent bits.
+func powerTooLarge(base *big.Int, exponent *big.Int) bool {
+	if base.CmpAbs(big.NewInt(1)) <= 0 {
+		return false
+	}
+	return !exponent.IsInt64() || exponent.Int64() > maxShift ||
+		int64(base.BitLen()-1)*exponent.Int64() > maxShift
+}
+
+// bitwise applies `&`, `|`, `^`, `<<` or `>>` to integer operands
+func bitwise(operator l.TokenType, token *l.Token, left *Value, right *Value) (*Value, error) {
+	if !isIntegerOperand(*left, *right) {
+		return nil, NewRuntimeError(token, "Operands must be integers")
+	}
+	a, b := toBigInt(*left), toBigInt(*right)
+	result := new(big.Int)
+	switch operator {
+	case l.AMPERSAND:
+		return NewValue(result.And(a, b)), nil
+	case l.PIPE:
+		return NewValue(result.Or(a, b)), nil
+	case l.CARET:
+		return NewValue(result.Xor(a, b)), nil
+	}
+	if b.Sign() < 0 {
+		return nil, NewRuntimeError(token, "Shift count can't be negative")
+	}
+	if operator == l.GREATER_GREATER {
+		if !b.IsInt64() || b.Int64() > maxShift {
+			// Every bit is shifted out
+			if a.Sign() < 0 {
+				return NewValue(int64(-1)), nil
+			}
+			return NewValue(int64(0)), nil
+		}
+		return NewValue(result.Rsh(a, uint(b.Int64()))), nil
+	}
+	if !b.IsInt64() || b.Int64() > maxShift {
+		return nil, NewRuntimeError(token, "Shift count too large")
+	}
+	return NewValue(result.Lsh(a, uint(b.Int64()))), nil
+}
+
+// compareNumbers returns -1, 0 or 1 as `left` is less than, equal to or
+// greater than `right`. It returns false when a float operand is NaN, or
+// infinite and compared to a decimal.
+func compareNumbers(left Value, right Value) (int, bool) {
+	if isIntegerOperand(left, right) {
+		return toBigInt(left).Cmp(toBigInt(right)), true
+	}
+	if left.DataType == DECIMAL_DT || right.DataType == DECIMAL_DT {
+		a, okA := toRat(left)
+		b, okB := toRat(right)
+		if !okA || !okB {
+			return 0, false
+		}
+		return a.Cmp(b), true
+	}
+	a, b := toNumber(left), toNumber(right)
+	switch {
+	case a < b:
+		return -1, true
+	case a > b:
+		return 1, true
+	case a == b:
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// toRat converts a number to a fraction, it returns false for the floats
+// NaN and infinities
+func toRat(value Value) (*big.Rat, bool) {
+	if value.DataType == NUMBER_DT {
+		number := value.Data.(float64)
+		if math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(number), true
+	}
+	if d, ok := toDecimal(value); ok {
+		return d.Rat(), true
+	}
+	return nil, false
+}
@@ -1,12 +1,23 @@
 package interpreter
 
 import (
+	"errors"
 	"fmt"
+	"sync/atomic"
 
+	l "github.com/debugg-er/lox/src/lexer"
 	"github.com/debugg-er/lox/src/parser"
 )
 
+// errStepsExhausted stops a program which executed its budget of
+// statements, see Interpreter.steps
+var errStepsExhausted = errors.New("Too many statements executed.")
+
 func (i *Interpreter) Execute(t parser.Stmt) error {
+	if i.steps != nil && atomic.AddInt64(i.steps, -1) < 0 {
+		return errStepsExhausted
+	}
+	i.coverage.hitStmt(t)
 	switch t := t.(type) {
 	case *parser.PrintStmt:
 		return i.executePrintStmt(t)
@@ -20,6 +31,10 @@ func (i *Interpreter) Execute(t parser.Stmt) error {
 		return i.executeIfStmt(t)
 	case *parser.WhileStmt:
 		return i.executeWhileStmt(t)
+	case *parser.DoWhileStmt:
+		return i.executeDoWhileStmt(t)
+	case *parser.LoopStmt:
+		return i.executeLoopStmt(t)
 	case *parser.ForStmt:
 		return i.executeForStmt(t)
 	case *parser.BreakStmt:
@@ -30,6 +45,19 @@ func (i *Interpreter) Execute(t parser.Stmt) error {
 		return i.executeFuncStmt(t)
 	case *parser.ReturnStmt:
 		return i.executeReturnStmt(t)
+	case *parser.YieldStmt:
+		return i.executeYieldStmt(t)
+	case *parser.ForInStmt:
+		return i.executeForInStmt(t)
+	case *parser.MatchStmt:
+		return i.executeMatchStmt(t)
+	case *parser.SpawnStmt:
+		return i.executeSpawnStmt(t)
+	case *parser.SelectStmt:
+		return i.executeSelectStmt(t)
+	case *parser.TestStmt:
+		// Test blocks are only run by the test runner
+		return nil
 	}
 	return nil
 }
@@ -40,7 +68,7 @@ func (i *Interpreter) executePrintStmt(t *parser.PrintStmt) error {
 	if err != nil {
 		return err
 	}
-	fmt.Println(value.Stringify())
+	fmt.Fprintln(i.stdout, value.Stringify())
 	return nil
 }
 
@@ -52,11 +80,22 @@ func (i *Interpreter) executeExprStmt(t *parser.ExprStmt) error {
 
 // ---------------- Variable Declaration Statement ----------------
 func (i *Interpreter) executeVarStmt(t *parser.VarStmt) error {
+	if t.Initilizer == nil {
+		i.env.define(t.Name, NewValue(nil))
+		return nil
+	}
 	value, err := i.Evaluate(t.Initilizer)
 	if err != nil {
 		return err
 	}
-	i.env.define(t.Name, value)
+	define := i.env.define
+	if t.Kind == l.CONST {
+		define = i.env.defineConstant
+	}
+	if t.Pattern != nil {
+		return destructure(t.Pattern, value, define)
+	}
+	define(t.Name, value)
 	return nil
 }
 
@@ -73,17 +112,10 @@ func (i *Interpreter) executeBlockStmt(t *parser.BlockStmt) error {
 		if err != nil {
 			return err
 		}
-		// Stop execute when break or continue is met on child statements
-		if executor := i.env.getLoopableTarget(); executor != nil {
-			if executor.IsBreaked() || executor.IsContinued() {
-				return nil
-			}
-		}
-		// Stop execute when return is met on child statements
-		if executor := i.env.getReturnableTarget(); executor != nil {
-			if executor.IsReturned() {
-				return nil
-			}
+		// Stop execute when break, continue or return is met on child
+		// statements
+		if i.jump != noJump {
+			return nil
 		}
 	}
 	return nil
@@ -95,40 +127,96 @@ func (i *Interpreter) executeIfStmt(t *parser.IfStmt) error {
 	if err != nil {
 		return err
 	}
+	i.coverage.hitBranch(t, isTruthy(*conditionValue))
 	if isTruthy(*conditionValue) {
-		i.Execute(t.ThenStmt)
+		return i.Execute(t.ThenStmt)
 	} else if t.ElseStmt != nil {
-		i.Execute(t.ElseStmt)
+		return i.Execute(t.ElseStmt)
 	}
 	return nil
 }
 
 // ---------------- While Statement ----------------
 func (i *Interpreter) executeWhileStmt(t *parser.WhileStmt) error {
-	i.env.loopableTarget = t
+	i.loops++
+	defer func() { i.loops-- }()
+
 	for {
 		conditionValue, err := i.Evaluate(t.Condition)
 		if err != nil {
 			return err
 		}
+		i.coverage.hitBranch(t, isTruthy(*conditionValue))
 		if !isTruthy(*conditionValue) {
 			return nil
 		}
-		i.Execute(t.Body)
-		if t.IsBreaked() {
+		if err := i.Execute(t.Body); err != nil {
+			return err
+		}
+		if i.endIteration(t.Label) {
+			return nil
+		}
+	}
+}
+
+// ---------------- Do While Statement ----------------
+// executeDoWhileStmt checks the condition after every iteration, a
+// continue included
+func (i *Interpreter) executeDoWhileStmt(t *parser.DoWhileStmt) error {
+	i.loops++
+	defer func() { i.loops-- }()
+
+	for {
+		if err := i.Execute(t.Body); err != nil {
+			return err
+		}
+		if i.endIteration(t.Label) {
+			return nil
+		}
+		conditionValue, err := i.Evaluate(t.Condition)
+		if err != nil {
+			return err
+		}
+		i.coverage.hitBranch(t, isTruthy(*conditionValue))
+		if !isTruthy(*conditionValue) {
 			return nil
 		}
-		if t.IsContinued() {
-			t.SetContinued(false)
+	}
+}
+
+// ---------------- Loop Statement ----------------
+func (i *Interpreter) executeLoopStmt(t *parser.LoopStmt) error {
+	i.loops++
+	defer func() { i.loops-- }()
+
+	for {
+		if err := i.Execute(t.Body); err != nil {
+			return err
+		}
+		if i.endIteration(t.Label) {
+			return nil
 		}
 	}
 }
 
 // ---------------- For Statement ----------------
+// executeForStmt runs a loop whose initialization declares a `let` with a
+// copy of the variable per iteration, so closures capture the value of
+// their iteration, while a `var` is shared by the iterations
 func (i *Interpreter) executeForStmt(t *parser.ForStmt) error {
-	i.env.loopableTarget = t
+	// The initialization is scoped to the loop
+	oldEnv := i.env
+	i.env = NewEnvironment(i.env)
+	i.loops++
+	defer func(env *Environment) {
+		i.env = env
+		i.loops--
+	}(oldEnv)
+
 	if t.Initialization != nil {
-		i.Execute(t.Initialization)
+		if err := i.Execute(t.Initialization); err != nil {
+			return err
+		}
 	}
 	for {
 		// Condition checking
@@ -137,64 +225,190 @@ func (i *Interpreter) executeForStmt(t *parser.ForStmt) error {
 			if err != nil {
 				return err
 			}
+			i.coverage.hitBranch(t, isTruthy(*conditionValue))
 			if !isTruthy(*conditionValue) {
 				return nil
 			}
 		}
 		// Body execution
-		i.Execute(t.Body)
-		if t.IsBreaked() {
+		if err := i.Execute(t.Body); err != nil {
+			return err
+		}
+		if i.endIteration(t.Label) {
 			return nil
 		}
-		if t.IsContinued() {
-			t.SetContinued(false)
+		if declaration, ok := t.Initialization.(*parser.VarStmt); ok && declaration.Kind == l.LET {
+			if err := i.nextIteration(declaration, oldEnv); err != nil {
+				return err
+			}
 		}
 		if t.Updation != nil {
-			i.Evaluate(t.Updation)
+			if _, err := i.Evaluate(t.Updation); err != nil {
+				return err
+			}
 		}
 	}
 }
 
+// nextIteration copies the variables of a `let` initialization to a new
+// environment for the next iteration of a for loop
+func (i *Interpreter) nextIteration(declaration *parser.VarStmt, enclosing *Environment) error {
+	names := []*l.Token{declaration.Name}
+	if declaration.Pattern != nil {
+		names = parser.Bindings(declaration.Pattern)
+	}
+	env := NewEnvironment(enclosing)
+	for _, name := range names {
+		value, err := i.env.get(name)
+		if err != nil {
+			return err
+		}
+		env.define(name, value)
+	}
+	i.env = env
+	return nil
+}
+
+// ---------------- For In Statement ----------------
+// executeForInStmt runs the body once per value of the iterable, every
+// iteration has its own scope so closures capture the value of their
+// iteration
+func (i *Interpreter) executeForInStmt(t *parser.ForInStmt) error {
+	iterable, err := i.Evaluate(t.Iterable)
+	if err != nil {
+		return err
+	}
+	next, err := i.iterate(iterable, t.Token)
+	if err != nil {
+		return err
+	}
+	oldEnv := i.env
+	i.loops++
+	defer func(env *Environment) {
+		i.env = env
+		i.loops--
+	}(oldEnv)
+
+	for {
+		value, ok, err := next()
+		if err != nil {
+			return err
+		}
+		i.coverage.hitBranch(t, ok)
+		if !ok {
+			return nil
+		}
+		i.env = NewEnvironment(oldEnv)
+		i.env.define(t.Name, value)
+		if err := i.Execute(t.Body); err != nil {
+			return err
+		}
+		if i.endIteration(t.Label) {
+			return nil
+		}
+	}
+}
+
+// endIteration is checked after every iteration, it consumes a break or
+// continue of the loop body and reports whether the loop must stop. A
+// break or continue naming another label stops the loop and is left to an
+// enclosing one.
+func (i *Interpreter) endIteration(label *l.Token) bool {
+	if (i.jump == breakJump || i.jump == continueJump) && i.label != "" {
+		if label == nil || label.Value != i.label {
+			return true
+		}
+		i.label = ""
+	}
+	switch i.jump {
+	case breakJump:
+		i.jump = noJump
+		return true
+	case continueJump:
+		i.jump = noJump
+		return false
+	case returnJump:
+		return true
+	default:
+		return false
+	}
+}
+
+// ---------------- Match Statement ----------------
+// executeMatchStmt runs the body of the first matching case in a scope
+// holding the names bound by its pattern
+func (i *Interpreter) executeMatchStmt(t *parser.MatchStmt) error {
+	subject, err := i.Evaluate(t.Subject)
+	if err != nil {
+		return err
+	}
+	for _, matchCase := range t.Cases {
+		for _, pattern := range matchCase.Patterns {
+			bindings := make([]binding, 0)
+			if matchPattern(pattern, subject, &bindings) {
+				return i.executeCase(matchCase, bindings)
+			}
+		}
+	}
+	return NewRuntimeError(t.Token, "No case matched "+subject.Repr()+".")
+}
+
+func (i *Interpreter) executeCase(matchCase *parser.MatchCase, bindings []binding) error {
+	oldEnv := i.env
+	i.env = NewEnvironment(i.env)
+	defer func(env *Environment) {
+		i.env = env
+	}(oldEnv)
+
+	for _, binding := range bindings {
+		i.env.define(binding.name, binding.value)
+	}
+	return i.Execute(matchCase.Body)
+}
+
 // ---------------- Break Statement ----------------
 func (i *Interpreter) executeBreakStmt(t *parser.BreakStmt) error {
-	executor := i.env.getLoopableTarget()
-	if executor != nil {
+	if i.loops == 0 {
 		return NewRuntimeError(t.Token, "RuntimeError: 'break' statement can only be used within an enclosing iteration")
 	}
-	executor.SetBreaked(true)
+	i.jump = breakJump
+	if t.Label != nil {
+		i.label = t.Label.Value.(string)
+	}
 	return nil
 }
 
 // ---------------- Continue Statement ----------------
 func (i *Interpreter) executeContinueStmt(t *parser.ContinueStmt) error {
-	executor := i.env.getLoopableTarget()
-	if executor == nil {
+	if i.loops == 0 {
 		return NewRuntimeError(t.Token, "RuntimeError: 'continue' statement can only be used within an enclosing iteration")
 	}
-	executor.SetContinued(true)
+	i.jump = continueJump
+	if t.Label != nil {
+		i.label = t.Label.Value.(string)
+	}
 	return nil
 }
 
 // ---------------- Function Statement ----------------
 func (i *Interpreter) executeFuncStmt(t *parser.FuncStmt) error {
-	if err := i.Execute(t.Body); err != nil {
-		return err
-	}
+	i.env.define(t.Name, NewValue(&Function{t, i.env, i.owner}))
 	return nil
 }
 
 // ---------------- Return Statement ----------------
 func (i *Interpreter) executeReturnStmt(t *parser.ReturnStmt) error {
-	executor := i.env.getReturnableTarget()
-	if executor == nil {
+	if i.callDepth == 0 {
 		return NewRuntimeError(t.Token, "RuntimeError: 'return' statement can only be used within an function")
 	}
-	value, err := i.Evaluate(t.Expr)
-	if err != nil {
-		return err
+	value := NewValue(nil)
+	if t.Expr != nil {
+		var err error
+		if value, err = i.Evaluate(t.Expr); err != nil {
+			return err
+		}
 	}
-	executor.SetIsReturned(true)
-	returnableTargetEnv := i.env.getReturnableTargetEnv()
-	returnableTargetEnv.returnValue = value
+	i.jump = returnJump
+	i.returnValue = value
 	return nil
 }
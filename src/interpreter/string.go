@@ -0,0 +1,170 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	l "github.com/debugg-er/lox/src/lexer"
+)
+
+// maxStringLength bounds the strings built by repeat, which would
+// otherwise exhaust the memory of the host
+const maxStringLength = 1 << 24
+
+type stringMethod struct {
+	arity int // -1 for variadic methods
+	call  func(s string, token *l.Token, arguments []*Value) (*Value, error)
+}
+
+var stringMethods = map[string]stringMethod{
+	"upper": {0, func(s string, token *l.Token, arguments []*Value) (*Value, error) {
+		return NewValue(strings.ToUpper(s)), nil
+	}},
+	"lower": {0, func(s string, token *l.Token, arguments []*Value) (*Value, error) {
+		return NewValue(strings.ToLower(s)), nil
+	}},
+	"trim": {0, func(s string, token *l.Token, arguments []*Value) (*Value, error) {
+		return NewValue(strings.TrimSpace(s)), nil
+	}},
+	"split": {1, stringSplit},
+	"join":  {1, stringJoin},
+	"replace": {2, func(s string, token *l.Token, arguments []*Value) (*Value, error) {
+		old, err := expectString(arguments[0], token)
+		if err != nil {
+			return nil, err
+		}
+		new, err := expectString(arguments[1], token)
+		if err != nil {
+			return nil, err
+		}
+		return NewValue(strings.ReplaceAll(s, old, new)), nil
+	}},
+	"find": {1, func(s string, token *l.Token, arguments []*Value) (*Value, error) {
+		substring, err := expectString(arguments[0], token)
+		if err != nil {
+			return nil, err
+		}
+		index := strings.Index(s, substring)
+		if index < 0 {
+			return NewValue(int64(-1)), nil
+		}
+		return NewValue(int64(utf8.RuneCountInString(s[:index]))), nil
+	}},
+	"startsWith": {1, func(s string, token *l.Token, arguments []*Value) (*Value, error) {
+		prefix, err := expectString(arguments[0], token)
+		if err != nil {
+			return nil, err
+		}
+		return NewValue(strings.HasPrefix(s, prefix)), nil
+	}},
+	"endsWith": {1, func(s string, token *l.Token, arguments []*Value) (*Value, error) {
+		suffix, err := expectString(arguments[0], token)
+		if err != nil {
+			return nil, err
+		}
+		return NewValue(strings.HasSuffix(s, suffix)), nil
+	}},
+	"repeat": {1, func(s string, token *l.Token, arguments []*Value) (*Value, error) {
+		count, err := toInteger(arguments[0], token)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, NewRuntimeError(token, "Repeat count must not be negative.")
+		}
+		if len(s) != 0 && count > maxStringLength/len(s) {
+			return nil, NewRuntimeError(token, "Repeated string is too long.")
+		}
+		return NewValue(strings.Repeat(s, count)), nil
+	}},
+	"format": {-1, stringFormat},
+}
+
+// stringProperty returns the method `name` of a string, bound to it
+func stringProperty(s string, name *l.Token) (*Value, error) {
+	method, ok := stringMethods[name.Value.(string)]
+	if !ok {
+		return nil, NewRuntimeError(name, "Undefined property '"+name.Value.(string)+"' of string.")
+	}
+	return NewValue(&NativeFunction{
+		Name:  name.Value.(string),
+		Arity: method.arity,
+		Call: func(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error) {
+			return method.call(s, token, arguments)
+		},
+	}), nil
+}
+
+// split("") splits a string into its characters
+func stringSplit(s string, token *l.Token, arguments []*Value) (*Value, error) {
+	separator, err := expectString(arguments[0], token)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(s, separator)
+	elements := make([]*Value, len(parts))
+	for j, part := range parts {
+		elements[j] = NewValue(part)
+	}
+	return NewValue(NewList(elements)), nil
+}
+
+// join uses the string as the separator of the list elements
+func stringJoin(s string, token *l.Token, arguments []*Value) (*Value, error) {
+	list, ok := arguments[0].Data.(*List)
+	if !ok {
+		return nil, NewRuntimeError(token, "Expected a list to join.")
+	}
+	parts := make([]string, list.Len())
+	for j, element := range list.Elements() {
+		parts[j] = element.Stringify()
+	}
+	return NewValue(strings.Join(parts, s)), nil
+}
+
+// format replaces `{}` placeholders by the arguments in order and `{n}` by
+// the n-th argument, `{{` and `}}` escape the braces
+func stringFormat(s string, token *l.Token, arguments []*Value) (*Value, error) {
+	var out strings.Builder
+	next := 0
+	for j := 0; j < len(s); j++ {
+		switch {
+		case strings.HasPrefix(s[j:], "{{"), strings.HasPrefix(s[j:], "}}"):
+			out.WriteByte(s[j])
+			j++
+		case s[j] == '{':
+			end := strings.IndexByte(s[j:], '}')
+			if end < 0 {
+				return nil, NewRuntimeError(token, "Unclosed '{' in format string.")
+			}
+			index := next
+			if placeholder := s[j+1 : j+end]; placeholder != "" {
+				position, err := strconv.Atoi(placeholder)
+				if err != nil {
+					return nil, NewRuntimeError(token, fmt.Sprintf("Invalid placeholder '{%s}' in format string.", placeholder))
+				}
+				index = position
+			} else {
+				next++
+			}
+			if index < 0 || index >= len(arguments) {
+				return nil, NewRuntimeError(token, fmt.Sprintf("Format string expects argument %d but got %d arguments.", index, len(arguments)))
+			}
+			out.WriteString(arguments[index].Stringify())
+			j += end
+		default:
+			out.WriteByte(s[j])
+		}
+	}
+	return NewValue(out.String()), nil
+}
+
+func expectString(value *Value, token *l.Token) (string, error) {
+	s, ok := value.Data.(string)
+	if !ok {
+		return "", NewRuntimeError(token, "Expected a string but got "+value.Repr()+".")
+	}
+	return s, nil
+}
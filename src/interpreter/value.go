@@ -1,15 +1,32 @@
 package interpreter
 
-import "fmt"
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
 
 type DataType int
 
 const (
 	NUMBER_DT DataType = iota
+	INTEGER_DT
+	BIG_INTEGER_DT
+	DECIMAL_DT
 	STRING_DT
 	BOOLEAN_DT
 	FUNCTION_DT
 	NULL_DT
+	LIST_DT
+	MAP_DT
+	RANGE_DT
+	GENERATOR_DT
+	CHANNEL_DT
+	WAIT_GROUP_DT
 )
 
 type Value struct {
@@ -17,12 +34,61 @@ type Value struct {
 	Data     interface{}
 }
 
+// NativeFunction is a function implemented in Go, it's stored as the Data
+// of a FUNCTION_DT value next to the user defined *Function
+type NativeFunction struct {
+	Name  string
+	Arity int // -1 for functions checking their arguments themselves
+	Call  func(i *Interpreter, token *l.Token, arguments []*Value) (*Value, error)
+}
+
+// Function is a user defined function along with the environment it was
+// declared in
+type Function struct {
+	Declaration *parser.FuncStmt
+	Closure     *Environment
+	// The generator whose body declared the function, which keeps the
+	// closure of the generator alive. A generator whose body declares
+	// functions is then kept alive by its own goroutine until it's done.
+	owner *Generator
+}
+
+func (v Value) Equals(other Value) bool {
+	// Numbers of different kinds are equal when their values are
+	if isNumber(v) && isNumber(other) {
+		order, ok := compareNumbers(v, other)
+		return ok && order == 0
+	}
+	return v.DataType == other.DataType && v.Data == other.Data
+}
+
+func isNumber(value Value) bool {
+	switch value.DataType {
+	case NUMBER_DT, INTEGER_DT, BIG_INTEGER_DT, DECIMAL_DT:
+		return true
+	default:
+		return false
+	}
+}
+
 func (v Value) Stringify() string {
+	return v.stringify(nil)
+}
+
+// stringify writes the collections of `visited`, the ones being written,
+// as "[...]" or "{...}" so a collection containing itself is finite
+func (v Value) stringify(visited map[interface{}]bool) string {
 	switch value := v.Data.(type) {
 	case string:
 		return value
 	case float64:
 		return fmt.Sprintf("%g", value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case *big.Int:
+		return value.String()
+	case *Decimal:
+		return value.String()
 	case bool:
 		if value {
 			return "true"
@@ -31,21 +97,122 @@ func (v Value) Stringify() string {
 		}
 	case nil:
 		return "null"
+	case *Function:
+		if value.Declaration.Name == nil {
+			return "<fn>"
+		}
+		return "<fn " + value.Declaration.Name.Value.(string) + ">"
+	case *NativeFunction:
+		return "<native fn " + value.Name + ">"
+	case *List:
+		if visited[value] {
+			return "[...]"
+		}
+		visited = visit(visited, value)
+		defer delete(visited, value)
+		elements := make([]string, value.Len())
+		for j, element := range value.Elements() {
+			elements[j] = element.repr(visited)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *Map:
+		if visited[value] {
+			return "{...}"
+		}
+		visited = visit(visited, value)
+		defer delete(visited, value)
+		entries := make([]string, 0, value.Len())
+		for _, key := range value.Keys() {
+			entry, _ := value.Get(key)
+			entries = append(entries, key.repr(visited)+": "+entry.repr(visited))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	case *Channel:
+		return "<channel>"
+	case *WaitGroup:
+		return "<wait group>"
+	case *Generator:
+		if value.Name == "" {
+			return "<generator>"
+		}
+		return "<generator " + value.Name + ">"
+	case *Range:
+		operator := ".."
+		if value.Inclusive {
+			operator = "..="
+		}
+		if value.Integer {
+			str := fmt.Sprintf("%d%s%d", value.IntStart, operator, value.IntEnd)
+			if value.IntStep != 1 {
+				str += fmt.Sprintf(" step %d", value.IntStep)
+			}
+			return str
+		}
+		str := fmt.Sprintf("%g%s%g", value.Start, operator, value.End)
+		if value.Step != 1 {
+			str += fmt.Sprintf(" step %g", value.Step)
+		}
+		return str
 	default:
 		return ""
 	}
 }
 
+// Repr is the representation of a value nested in a collection, where
+// strings are quoted
+func (v Value) Repr() string {
+	return v.repr(nil)
+}
+
+func (v Value) repr(visited map[interface{}]bool) string {
+	if v.DataType == STRING_DT {
+		return strconv.Quote(v.Data.(string))
+	}
+	return v.stringify(visited)
+}
+
+func visit(visited map[interface{}]bool, collection interface{}) map[interface{}]bool {
+	if visited == nil {
+		visited = map[interface{}]bool{}
+	}
+	visited[collection] = true
+	return visited
+}
+
 func NewValue(data interface{}) *Value {
 	switch value := data.(type) {
 	case string:
 		return &Value{STRING_DT, value}
 	case float64:
 		return &Value{NUMBER_DT, value}
+	case int64:
+		return &Value{INTEGER_DT, value}
+	case *big.Int:
+		// Integers fitting an int64 are never big
+		if value.IsInt64() {
+			return &Value{INTEGER_DT, value.Int64()}
+		}
+		return &Value{BIG_INTEGER_DT, value}
+	case *Decimal:
+		return &Value{DECIMAL_DT, value}
 	case bool:
 		return &Value{BOOLEAN_DT, value}
 	case nil:
 		return &Value{NULL_DT, value}
+	case *List:
+		return &Value{LIST_DT, value}
+	case *Map:
+		return &Value{MAP_DT, value}
+	case *Range:
+		return &Value{RANGE_DT, value}
+	case *Generator:
+		return &Value{GENERATOR_DT, value}
+	case *Channel:
+		return &Value{CHANNEL_DT, value}
+	case *WaitGroup:
+		return &Value{WAIT_GROUP_DT, value}
+	case *Function, *NativeFunction:
+		return &Value{FUNCTION_DT, value}
 	default:
 		panic("Language fatal: Undefined datatype")
 	}
@@ -3,33 +3,49 @@ package lexer
 import (
 	"bytes"
 	"fmt"
-	"strconv"
+	"strings"
 )
 
 type Lexer struct {
-	source  string
-	current int
-	line    int
-	tokens  []Token
+	source         string
+	current        int
+	line           int
+	tokens         []Token
+	interpolations []interpolation
+	comments       []Comment // Comments preceding the next token
+}
+
+// interpolation is an embedded expression of a string being scanned
+type interpolation struct {
+	line   int
+	braces int // Depth of the braces opened in the expression
 }
 
 func NewLexer() *Lexer {
 	return &Lexer{
-		current: 0,
-		line:    1,
-		source:  "",
-		tokens:  make([]Token, 0),
+		current:        0,
+		line:           1,
+		source:         "",
+		tokens:         make([]Token, 0),
+		interpolations: make([]interpolation, 0),
 	}
 }
 
 func (lexer *Lexer) Parse(source string) ([]Token, error) {
 	lexer.source = source
+	// The shebang line of an executable script
+	if strings.HasPrefix(source, "#!") {
+		lexer.lineComment()
+	}
 	for !lexer.isAtEnd() {
 		err := lexer.scanToken()
 		if err != nil {
 			return nil, err
 		}
 	}
+	if depth := len(lexer.interpolations); depth != 0 {
+		return nil, fmt.Errorf("SyntaxError: Expected '}' after interpolation at line %d", lexer.interpolations[depth-1].line)
+	}
 	lexer.addToken(EOF, nil)
 	return lexer.tokens, nil
 }
@@ -43,49 +59,128 @@ func (lexer *Lexer) scanToken() error {
 	case ')':
 		lexer.addToken(RIGHT_PAREN, nil)
 	case '{':
+		if depth := len(lexer.interpolations); depth != 0 {
+			lexer.interpolations[depth-1].braces++
+		}
 		lexer.addToken(LEFT_BRACE, nil)
 	case '}':
+		if depth := len(lexer.interpolations); depth != 0 {
+			// The brace closing an interpolation resumes its string
+			if lexer.interpolations[depth-1].braces == 0 {
+				if lexer.tokens[len(lexer.tokens)-1].Type == INTERPOLATION {
+					return fmt.Errorf("SyntaxError: Expected expression in interpolation at line %d", lexer.line)
+				}
+				lexer.interpolations = lexer.interpolations[:depth-1]
+				return lexer.string()
+			}
+			lexer.interpolations[depth-1].braces--
+		}
 		lexer.addToken(RIGHT_BRACE, nil)
+	case '[':
+		lexer.addToken(LEFT_BRACKET, nil)
+	case ']':
+		lexer.addToken(RIGHT_BRACKET, nil)
 	case ',':
 		lexer.addToken(COMMA, nil)
+	case ':':
+		lexer.addToken(COLON, nil)
 	case '.':
-		lexer.addToken(DOT, nil)
+		if strings.HasPrefix(lexer.source[lexer.current:], "..") {
+			lexer.current = lexer.current + 2
+			lexer.addToken(ELLIPSIS, nil)
+		} else if lexer.match('.') {
+			if lexer.match('=') {
+				lexer.addToken(DOT_DOT_EQUAL, nil)
+			} else {
+				lexer.addToken(DOT_DOT, nil)
+			}
+		} else {
+			lexer.addToken(DOT, nil)
+		}
 	case '-':
-		lexer.addToken(MINUS, nil)
+		if lexer.match('-') {
+			lexer.addToken(MINUS_MINUS, nil)
+		} else if lexer.match('=') {
+			lexer.addToken(MINUS_EQUAL, nil)
+		} else {
+			lexer.addToken(MINUS, nil)
+		}
 	case '+':
-		lexer.addToken(PLUS, nil)
+		if lexer.match('+') {
+			lexer.addToken(PLUS_PLUS, nil)
+		} else if lexer.match('=') {
+			lexer.addToken(PLUS_EQUAL, nil)
+		} else {
+			lexer.addToken(PLUS, nil)
+		}
 	case '*':
-		lexer.addToken(STAR, nil)
+		if lexer.match('*') {
+			lexer.addToken(STAR_STAR, nil)
+		} else if lexer.match('=') {
+			lexer.addToken(STAR_EQUAL, nil)
+		} else {
+			lexer.addToken(STAR, nil)
+		}
+	case '%':
+		if lexer.match('=') {
+			lexer.addToken(PERCENT_EQUAL, nil)
+		} else {
+			lexer.addToken(PERCENT, nil)
+		}
+	case '?':
+		if lexer.match('?') {
+			lexer.addToken(QUESTION_QUESTION, nil)
+		} else if lexer.match('.') {
+			lexer.addToken(QUESTION_DOT, nil)
+		} else {
+			lexer.addToken(QUESTION, nil)
+		}
+	case '&':
+		lexer.addToken(AMPERSAND, nil)
+	case '|':
+		lexer.addToken(PIPE, nil)
+	case '^':
+		lexer.addToken(CARET, nil)
+	case '~':
+		lexer.addToken(TILDE, nil)
 	case ';':
 		lexer.addToken(SEMICOLON, nil)
-	case '\r':
-	case ' ':
+	case ' ', '\t', '\r', '\v', '\f':
 		break
 	case '\n':
 		lexer.line = lexer.line + 1
 	case '/':
-		if lexer.match('/') {
-			for !lexer.isAtEnd() && lexer.peek() != '\n' {
-				lexer.advance()
-			}
+		if lexer.peekIs('/') {
+			lexer.current--
+			lexer.lineComment()
+		} else if lexer.match('*') {
+			return lexer.blockComment()
+		} else if lexer.match('=') {
+			lexer.addToken(SLASH_EQUAL, nil)
 		} else {
 			lexer.addToken(SLASH, nil)
 		}
 	case '>':
 		if lexer.match('=') {
 			lexer.addToken(GREATER_EQUAL, nil)
+		} else if lexer.match('>') {
+			lexer.addToken(GREATER_GREATER, nil)
 		} else {
 			lexer.addToken(GREATER, nil)
 		}
 	case '<':
 		if lexer.match('=') {
 			lexer.addToken(LESS_EQUAL, nil)
+		} else if lexer.match('<') {
+			lexer.addToken(LESS_LESS, nil)
 		} else {
 			lexer.addToken(LESS, nil)
 		}
 	case '=':
 		if lexer.match('=') {
 			lexer.addToken(EQUAL_EQUAL, nil)
+		} else if lexer.match('>') {
+			lexer.addToken(ARROW, nil)
 		} else {
 			lexer.addToken(EQUAL, nil)
 		}
@@ -97,10 +192,16 @@ func (lexer *Lexer) scanToken() error {
 		}
 
 	case '"':
+		if strings.HasPrefix(lexer.source[lexer.current:], `""`) {
+			lexer.current += 2
+			return lexer.textBlock()
+		}
 		err := lexer.string()
 		if err != nil {
 			return err
 		}
+	case '`':
+		return lexer.rawString()
 	default:
 		if isDigit(c) {
 			err := lexer.number()
@@ -117,42 +218,69 @@ func (lexer *Lexer) scanToken() error {
 	return nil
 }
 
-func (lexer *Lexer) string() error {
-	var str bytes.Buffer
-	for !lexer.isAtEnd() && !lexer.match('"') {
-		c := lexer.advance()
-		if c == '\\' {
-			str.WriteByte(escapeSequence(lexer.advance()))
-		} else {
-			str.WriteByte(c)
-		}
-	}
-	if lexer.isAtEnd() && lexer.previous() != '"' {
-		return fmt.Errorf("SyntaxError: Expected '\"' at line %d", lexer.line)
+// lineComment consumes a comment ending with the line
+func (lexer *Lexer) lineComment() {
+	start := lexer.current
+	for !lexer.isAtEnd() && lexer.peek() != '\n' {
+		lexer.advance()
 	}
+	lexer.comments = append(lexer.comments, Comment{lexer.source[start:lexer.current], lexer.line})
+}
 
-	lexer.addToken(STRING, str.String())
+// blockComment consumes a `/* ... */` comment, comments nested in it
+// included, after its opening delimiter
+func (lexer *Lexer) blockComment() error {
+	start := lexer.current - 2
+	startLine := lexer.line
+	for depth := 1; depth > 0; {
+		if lexer.isAtEnd() {
+			return fmt.Errorf("SyntaxError: Expected '*/' for comment starting at line %d", startLine)
+		}
+		switch c := lexer.advance(); {
+		case c == '\n':
+			lexer.line = lexer.line + 1
+		case c == '/' && lexer.match('*'):
+			depth++
+		case c == '*' && lexer.match('/'):
+			depth--
+		}
+	}
+	lexer.comments = append(lexer.comments, Comment{lexer.source[start:lexer.current], startLine})
 	return nil
 }
 
-func (lexer *Lexer) number() error {
-	start := lexer.current - 1
-	for !lexer.isAtEnd() && (isDigit(lexer.peek()) || lexer.peek() == '.') {
-		lexer.advance()
+func (lexer *Lexer) string() error {
+	var str bytes.Buffer
+	startLine := lexer.line
+	for {
+		if lexer.isAtEnd() {
+			return fmt.Errorf("SyntaxError: Expected '\"' for string starting at line %d", startLine)
+		}
+		c := lexer.advance()
+		if c == '"' {
+			break
+		}
+		if c == '$' && lexer.match('{') {
+			lexer.addToken(INTERPOLATION, str.String())
+			lexer.interpolations = append(lexer.interpolations, interpolation{line: lexer.line})
+			return nil
+		}
+		if c == '\n' {
+			lexer.line = lexer.line + 1
+		}
+		if c == '\\' && !lexer.isAtEnd() {
+			c = escapeSequence(lexer.advance())
+		}
+		str.WriteByte(c)
 	}
 
-	value := lexer.source[start:lexer.current]
-	num, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return fmt.Errorf("SyntaxError: Unexpected token at line %d", lexer.line)
-	}
-	lexer.addToken(NUMBER, num)
+	lexer.addToken(STRING, str.String())
 	return nil
 }
 
 func (lexer *Lexer) identifier() {
 	start := lexer.current - 1
-	for isAlphabet(lexer.peek()) && !lexer.isAtEnd() {
+	for !lexer.isAtEnd() && isAlphabet(lexer.peek()) {
 		lexer.advance()
 	}
 
@@ -172,10 +300,12 @@ func (lexer *Lexer) identifier() {
 
 func (lexer *Lexer) addToken(_type TokenType, value interface{}) {
 	token := Token{
-		Type:  TokenType(_type),
-		Value: value,
-		Line:  lexer.line,
+		Type:     TokenType(_type),
+		Value:    value,
+		Line:     lexer.line,
+		Comments: lexer.comments,
 	}
+	lexer.comments = nil
 	lexer.tokens = append(lexer.tokens, token)
 }
 
@@ -189,10 +319,6 @@ func (lexer *Lexer) peek() byte {
 	return lexer.source[lexer.current]
 }
 
-func (lexer *Lexer) previous() byte {
-	return lexer.source[lexer.current-1]
-}
-
 func (lexer *Lexer) isAtEnd() bool {
 	return lexer.current == len(lexer.source)
 }
@@ -0,0 +1,62 @@
+package lexer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func FuzzLexer(f *testing.F) {
+	for _, seed := range []string{
+		"var a = 1;",
+		"print \"abc\" + 1.5;",
+		"a",
+		"\"abc\\",
+		"\"abc\\\"",
+		"fun f(a, b) { return a >= b; } // comment",
+		"1.2.3",
+		"19.99d + 5d - 5day",
+		"0x1F + 0o7 + 0b1 + 1_000 + 6.02e23 + 1e+",
+		"a << 2 >> 3 & ~4 | 5 ^ 99999999999999999999",
+		"@",
+		"#!/usr/bin/env lox\nprint 1;",
+		"/* a /* nested */ comment */ print\t1;",
+		"/* unterminated /* */",
+		"/*/",
+		"`raw \\n\nstring`",
+		"\"\"\"\n    text\n  block\\\"\"\"\"\n  \"\"\"",
+		"\"\"\"\"\"",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, source string) {
+		tokens, err := NewLexer().Parse(source)
+		if err != nil {
+			return
+		}
+		if len(tokens) == 0 || tokens[len(tokens)-1].Type != EOF {
+			t.Fatalf("token stream of %q does not end with EOF", source)
+		}
+	})
+}
+
+func TestComments(t *testing.T) {
+	source := "#!/usr/bin/env lox\n// first\n/* second\n */ print 1; // third\n/* last */"
+	tokens, err := NewLexer().Parse(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]Comment{
+		{{"#!/usr/bin/env lox", 1}, {"// first", 2}, {"/* second\n */", 3}},
+		nil,
+		nil,
+		{{"// third", 4}, {"/* last */", 5}},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for j, token := range tokens {
+		if !reflect.DeepEqual(token.Comments, expected[j]) {
+			t.Errorf("comments of token %d: expected %q, got %q", j, expected[j], token.Comments)
+		}
+	}
+}
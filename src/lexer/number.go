@@ -0,0 +1,167 @@
+package lexer
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// number scans a number literal: an integer, in base 10 or in hexadecimal
+// `0xFF`, octal `0o17` or binary `0b1010`, a float `6.02e23` or a decimal
+// `19.99d`. Digits may be separated by underscores: `1_000_000`.
+//
+// An integer is an int64 token value, or a *big.Int when it doesn't fit
+// one, and a float a float64. The value of a decimal is its literal without
+// the suffix and the underscores.
+func (lexer *Lexer) number() error {
+	start := lexer.current - 1
+	if lexer.source[start] == '0' && !lexer.isAtEnd() {
+		switch lexer.peek() {
+		case 'x', 'X':
+			return lexer.radixNumber(start, 16, "hexadecimal")
+		case 'o', 'O':
+			return lexer.radixNumber(start, 8, "octal")
+		case 'b', 'B':
+			return lexer.radixNumber(start, 2, "binary")
+		}
+	}
+
+	lexer.current = start
+	if err := lexer.digits(start, 10); err != nil {
+		return err
+	}
+	float := false
+	// A dot not followed by a digit starts a range or a property access
+	if lexer.peekIs('.') && lexer.current+1 < len(lexer.source) && isDigit(lexer.source[lexer.current+1]) {
+		lexer.advance()
+		if err := lexer.digits(start, 10); err != nil {
+			return err
+		}
+		float = true
+	}
+	if lexer.peekIs('e') || lexer.peekIs('E') {
+		lexer.advance()
+		if lexer.peekIs('+') || lexer.peekIs('-') {
+			lexer.advance()
+		}
+		if lexer.isAtEnd() || !isDigit(lexer.peek()) {
+			return lexer.numberError(start, lexer.current, "Expected digits in the exponent of number")
+		}
+		if err := lexer.digits(start, 10); err != nil {
+			return err
+		}
+		float = true
+	}
+	literal := strings.ReplaceAll(lexer.source[start:lexer.current], "_", "")
+	if lexer.decimalSuffix() {
+		lexer.addToken(DECIMAL, literal)
+		return nil
+	}
+	if lexer.peekIs('.') && lexer.current+1 < len(lexer.source) && isDigit(lexer.source[lexer.current+1]) {
+		return lexer.numberError(start, lexer.current, "Unexpected '.' in number")
+	}
+	if err := lexer.checkEnd(start, "Unexpected character '%c' in number"); err != nil {
+		return err
+	}
+
+	if float {
+		num, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return lexer.numberError(start, start, "Out of range number")
+		}
+		lexer.addToken(NUMBER, num)
+		return nil
+	}
+	lexer.addToken(NUMBER, parseInteger(literal, 10))
+	return nil
+}
+
+// radixNumber scans an integer literal having a base prefix
+func (lexer *Lexer) radixNumber(start int, base int, name string) error {
+	lexer.advance()
+	if lexer.isAtEnd() || !isDigitOf(lexer.peek(), base) {
+		return lexer.numberError(start, lexer.current, "Expected "+name+" digits after '"+lexer.source[start:lexer.current]+"' in number")
+	}
+	if err := lexer.digits(start, base); err != nil {
+		return err
+	}
+	if err := lexer.checkEnd(start, "Invalid digit '%c' in "+name+" literal"); err != nil {
+		return err
+	}
+	literal := strings.ReplaceAll(lexer.source[start+2:lexer.current], "_", "")
+	lexer.addToken(NUMBER, parseInteger(literal, base))
+	return nil
+}
+
+// digits consumes the digits of a number in `base`, along with the
+// underscores separating them
+func (lexer *Lexer) digits(start int, base int) error {
+	for !lexer.isAtEnd() {
+		c := lexer.peek()
+		if c == '_' {
+			next := lexer.current + 1
+			if !isDigitOf(lexer.source[lexer.current-1], base) || next == len(lexer.source) || !isDigitOf(lexer.source[next], base) {
+				return lexer.numberError(start, lexer.current, "Digit separator '_' must be between digits in number")
+			}
+		} else if !isDigitOf(c, base) {
+			return nil
+		}
+		lexer.advance()
+	}
+	return nil
+}
+
+// checkEnd reports a letter or a digit glued to the end of a number
+func (lexer *Lexer) checkEnd(start int, format string) error {
+	if !lexer.isAtEnd() && (isAlphabet(lexer.peek()) || isDigit(lexer.peek())) {
+		return lexer.numberError(start, lexer.current, fmt.Sprintf(format, lexer.peek()))
+	}
+	return nil
+}
+
+// decimalSuffix consumes the `d` ending a decimal literal
+func (lexer *Lexer) decimalSuffix() bool {
+	if !lexer.peekIs('d') {
+		return false
+	}
+	if next := lexer.current + 1; next < len(lexer.source) && (isAlphabet(lexer.source[next]) || isDigit(lexer.source[next])) {
+		return false
+	}
+	lexer.advance()
+	return true
+}
+
+// numberError reports a malformed number literal starting at `start`,
+// pointing at the column of the offending character at `position`. The
+// message ends with the literal.
+func (lexer *Lexer) numberError(start int, position int, message string) error {
+	end := position
+	for end < len(lexer.source) && (isAlphabet(lexer.source[end]) || isDigit(lexer.source[end]) || lexer.source[end] == '.') {
+		end++
+	}
+	column := position - strings.LastIndexByte(lexer.source[:position], '\n')
+	return fmt.Errorf("SyntaxError: %s '%s' at line %d, column %d", message, lexer.source[start:end], lexer.line, column)
+}
+
+// parseInteger parses valid digits in `base`
+func parseInteger(digits string, base int) interface{} {
+	if num, err := strconv.ParseInt(digits, base, 64); err == nil {
+		return num
+	}
+	num, _ := new(big.Int).SetString(digits, base)
+	return num
+}
+
+func (lexer *Lexer) peekIs(c byte) bool {
+	return !lexer.isAtEnd() && lexer.peek() == c
+}
+
+func isDigitOf(c byte, base int) bool {
+	switch {
+	case base == 16:
+		return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+	default:
+		return c >= '0' && c < '0'+byte(base)
+	}
+}
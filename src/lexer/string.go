@@ -0,0 +1,124 @@
+package lexer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// rawString scans a `...` string, taken as is: it has neither escape
+// sequences nor interpolations and may span lines
+func (lexer *Lexer) rawString() error {
+	start := lexer.current
+	startLine := lexer.line
+	for {
+		if lexer.isAtEnd() {
+			return fmt.Errorf("SyntaxError: Expected '`' for raw string starting at line %d", startLine)
+		}
+		c := lexer.advance()
+		if c == '`' {
+			break
+		}
+		if c == '\n' {
+			lexer.line = lexer.line + 1
+		}
+	}
+	lexer.addToken(STRING, lexer.source[start:lexer.current-1])
+	return nil
+}
+
+// textBlock scans a """...""" string. Its escape sequences are processed
+// but it has no interpolations. The indentation its lines have in common
+// is stripped, along with the line break following the opening quotes and
+// the blank line preceding the closing ones:
+//
+//	var query = """
+//	    SELECT *
+//	    FROM users
+//	    """;
+//
+// is "SELECT *\nFROM users".
+func (lexer *Lexer) textBlock() error {
+	start := lexer.current
+	startLine := lexer.line
+	for !strings.HasPrefix(lexer.source[lexer.current:], `"""`) {
+		if lexer.isAtEnd() {
+			return fmt.Errorf("SyntaxError: Expected '\"\"\"' for string starting at line %d", startLine)
+		}
+		c := lexer.advance()
+		if c == '\n' {
+			lexer.line = lexer.line + 1
+		}
+		if c == '\\' && !lexer.isAtEnd() && lexer.advance() == '\n' {
+			lexer.line = lexer.line + 1
+		}
+	}
+	content := lexer.source[start:lexer.current]
+	lexer.current += 3
+	lexer.addToken(STRING, unescape(dedent(content)))
+	return nil
+}
+
+// dedent strips the indentation common to the lines of a text block, the
+// blank ones aside
+func dedent(content string) string {
+	lines := strings.Split(content, "\n")
+	if len(lines) == 1 {
+		return content
+	}
+	if isBlank(lines[0]) {
+		lines = lines[1:]
+	}
+	indent, measured := "", false
+	measure := func(prefix string) {
+		if measured {
+			indent = commonPrefix(indent, prefix)
+		} else {
+			indent, measured = prefix, true
+		}
+	}
+	for _, line := range lines {
+		if !isBlank(line) {
+			measure(line[:len(line)-len(strings.TrimLeft(line, " \t"))])
+		}
+	}
+	// The indentation of the closing quotes counts as well
+	if last := lines[len(lines)-1]; isBlank(last) {
+		measure(last)
+		lines = lines[:len(lines)-1]
+	}
+	for j, line := range lines {
+		if isBlank(line) {
+			lines[j] = ""
+		} else {
+			lines[j] = line[len(indent):]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isBlank(line string) bool {
+	return strings.TrimLeft(line, " \t\r") == ""
+}
+
+func commonPrefix(a string, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
+
+// unescape processes the escape sequences of a string
+func unescape(str string) string {
+	var result bytes.Buffer
+	for j := 0; j < len(str); j++ {
+		c := str[j]
+		if c == '\\' && j+1 < len(str) {
+			j++
+			c = escapeSequence(str[j])
+		}
+		result.WriteByte(c)
+	}
+	return result.String()
+}
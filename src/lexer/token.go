@@ -6,32 +6,64 @@ const (
 	Undefined TokenType = ""
 
 	// Single-character tokens.
-	LEFT_PAREN  = "("
-	RIGHT_PAREN = ")"
-	LEFT_BRACE  = "{"
-	RIGHT_BRACE = "}"
-	COMMA       = ","
-	DOT         = "."
-	MINUS       = "-"
-	PLUS        = "+"
-	SEMICOLON   = ";"
-	SLASH       = "/"
-	STAR        = "*"
+	LEFT_PAREN    = "("
+	RIGHT_PAREN   = ")"
+	LEFT_BRACE    = "{"
+	RIGHT_BRACE   = "}"
+	LEFT_BRACKET  = "["
+	RIGHT_BRACKET = "]"
+	COMMA         = ","
+	COLON         = ":"
+	DOT           = "."
+	MINUS         = "-"
+	PLUS          = "+"
+	SEMICOLON     = ";"
+	SLASH         = "/"
+	STAR          = "*"
+	PERCENT       = "%"
+	QUESTION      = "?"
+	AMPERSAND     = "&"
+	PIPE          = "|"
+	CARET         = "^"
+	TILDE         = "~"
 
 	// One or two character tokens.
-	BANG          = "!"
-	BANG_EQUAL    = "!="
-	EQUAL         = "="
-	EQUAL_EQUAL   = "=="
-	GREATER       = ">"
-	GREATER_EQUAL = ">="
-	LESS          = "<"
-	LESS_EQUAL    = "<="
+	BANG            = "!"
+	BANG_EQUAL      = "!="
+	EQUAL           = "="
+	EQUAL_EQUAL     = "=="
+	GREATER         = ">"
+	GREATER_EQUAL   = ">="
+	LESS            = "<"
+	LESS_EQUAL      = "<="
+	ARROW           = "=>"
+	DOT_DOT         = ".."
+	DOT_DOT_EQUAL   = "..="
+	ELLIPSIS        = "..."
+	PLUS_EQUAL      = "+="
+	MINUS_EQUAL     = "-="
+	STAR_EQUAL      = "*="
+	SLASH_EQUAL     = "/="
+	PERCENT_EQUAL   = "%="
+	PLUS_PLUS       = "++"
+	MINUS_MINUS     = "--"
+	STAR_STAR       = "**"
+	QUESTION_DOT    = "?."
+	LESS_LESS       = "<<"
+	GREATER_GREATER = ">>"
+	// Null-coalescing operator
+	QUESTION_QUESTION = "??"
 
 	// Literals.
 	IDENTIFIER = "identifier"
 	STRING     = "string"
 	NUMBER     = "number"
+	// A decimal literal `19.99d`, its value is the literal without the
+	// suffix
+	DECIMAL = "decimal"
+	// A string segment followed by an interpolated expression, the
+	// interpolation ends with the STRING token of the last segment
+	INTERPOLATION = "interpolation"
 
 	// Keywords.
 	AND      = "and"
@@ -49,9 +81,17 @@ const (
 	THIS     = "this"
 	TRUE     = "true"
 	VAR      = "var"
+	LET      = "let"
+	CONST    = "const"
 	WHILE    = "while"
 	BREAK    = "break"
 	CONTINUE = "continue"
+	MATCH    = "match"
+	CASE     = "case"
+	IN       = "in"
+	YIELD    = "yield"
+	SPAWN    = "spawn"
+	SELECT   = "select"
 	EOF      = "EOF"
 )
 
@@ -59,10 +99,22 @@ type Token struct {
 	Type  TokenType
 	Value interface{}
 	Line  int
+	// Comments preceding the token, for tools such as formatters. The
+	// comments at the end of the source precede the EOF token.
+	Comments []Comment
+}
+
+// Comment is a `// ...` or `/* ... */` comment, or the shebang line, with
+// its delimiters
+type Comment struct {
+	Text string
+	Line int // Line the comment starts at
 }
 
 var Keywords = map[string]TokenType{
 	"var":      VAR,
+	"let":      LET,
+	"const":    CONST,
 	"and":      AND,
 	"or":       OR,
 	"if":       IF,
@@ -77,4 +129,10 @@ var Keywords = map[string]TokenType{
 	"return":   RETURN,
 	"break":    BREAK,
 	"continue": CONTINUE,
+	"match":    MATCH,
+	"case":     CASE,
+	"in":       IN,
+	"yield":    YIELD,
+	"spawn":    SPAWN,
+	"select":   SELECT,
 }
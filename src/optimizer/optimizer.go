@@ -0,0 +1,276 @@
+package optimizer
+
+import (
+	"math/big"
+
+	"github.com/debugg-er/lox/src/interpreter"
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+type optimizer struct {
+	// Constant expressions are folded by the interpreter itself, so the
+	// folded values can't differ from the ones computed at runtime
+	evaluator *interpreter.Interpreter
+}
+
+// Optimize folds constant expressions and removes the statements that can
+// never run. Expressions failing at runtime, such as a division by zero,
+// are kept as they are so the error is still reported at its line.
+func Optimize(statements []parser.Stmt) []parser.Stmt {
+	o := &optimizer{evaluator: interpreter.NewInterpreter()}
+	return o.block(statements)
+}
+
+func (o *optimizer) block(statements []parser.Stmt) []parser.Stmt {
+	optimized := make([]parser.Stmt, 0, len(statements))
+	for _, stmt := range statements {
+		stmt = o.stmt(stmt)
+		if stmt == nil {
+			continue
+		}
+		optimized = append(optimized, stmt)
+		// Statements after a jump are unreachable
+		switch stmt.(type) {
+		case *parser.ReturnStmt, *parser.BreakStmt, *parser.ContinueStmt:
+			return optimized
+		}
+	}
+	return optimized
+}
+
+// stmt returns the optimized statement, or nil when it can be removed
+func (o *optimizer) stmt(stmt parser.Stmt) parser.Stmt {
+	switch stmt := stmt.(type) {
+	case *parser.PrintStmt:
+		stmt.Expr = o.expr(stmt.Expr)
+	case *parser.ExprStmt:
+		stmt.Expr = o.expr(stmt.Expr)
+	case *parser.VarStmt:
+		stmt.Initilizer = o.expr(stmt.Initilizer)
+	case *parser.ReturnStmt:
+		stmt.Expr = o.expr(stmt.Expr)
+	case *parser.YieldStmt:
+		stmt.Expr = o.expr(stmt.Expr)
+	case *parser.BlockStmt:
+		stmt.Declarations = o.block(stmt.Declarations)
+	case *parser.FuncStmt:
+		for _, parameter := range stmt.Parameters {
+			parameter.Default = o.expr(parameter.Default)
+		}
+		stmt.Body.Declarations = o.block(stmt.Body.Declarations)
+	case *parser.TestStmt:
+		stmt.Body.Declarations = o.block(stmt.Body.Declarations)
+	case *parser.ForInStmt:
+		stmt.Iterable = o.expr(stmt.Iterable)
+		stmt.Body = o.body(stmt.Body)
+	case *parser.MatchStmt:
+		stmt.Subject = o.expr(stmt.Subject)
+		for _, matchCase := range stmt.Cases {
+			matchCase.Body = o.body(matchCase.Body)
+		}
+	case *parser.SpawnStmt:
+		o.expr(stmt.Call)
+	case *parser.SelectStmt:
+		for _, selectCase := range stmt.Cases {
+			selectCase.Channel = o.expr(selectCase.Channel)
+			selectCase.Value = o.expr(selectCase.Value)
+			selectCase.Body = o.body(selectCase.Body)
+		}
+		if stmt.Default != nil {
+			stmt.Default = o.body(stmt.Default)
+		}
+	case *parser.IfStmt:
+		stmt.Condition = o.expr(stmt.Condition)
+		stmt.ThenStmt = o.body(stmt.ThenStmt)
+		stmt.ElseStmt = o.stmt(stmt.ElseStmt)
+		if condition, ok := o.truthiness(stmt.Condition); ok {
+			if condition {
+				return stmt.ThenStmt
+			}
+			return stmt.ElseStmt
+		}
+	case *parser.WhileStmt:
+		stmt.Condition = o.expr(stmt.Condition)
+		stmt.Body = o.body(stmt.Body)
+		if condition, ok := o.truthiness(stmt.Condition); ok && !condition {
+			return nil
+		}
+	case *parser.DoWhileStmt:
+		// The body runs once whatever the condition
+		stmt.Body = o.body(stmt.Body)
+		stmt.Condition = o.expr(stmt.Condition)
+	case *parser.LoopStmt:
+		stmt.Body = o.body(stmt.Body)
+	case *parser.ForStmt:
+		stmt.Initialization = o.stmt(stmt.Initialization)
+		stmt.Condition = o.expr(stmt.Condition)
+		stmt.Updation = o.expr(stmt.Updation)
+		stmt.Body = o.body(stmt.Body)
+		if condition, ok := o.truthiness(stmt.Condition); ok && !condition {
+			if stmt.Initialization == nil {
+				return nil
+			}
+			// The initialization still runs, in the scope of the loop
+			return &parser.BlockStmt{Declarations: []parser.Stmt{stmt.Initialization}}
+		}
+	}
+	return stmt
+}
+
+// body optimizes a statement which can't be removed, such as a loop body
+func (o *optimizer) body(stmt parser.Stmt) parser.Stmt {
+	stmt = o.stmt(stmt)
+	if stmt == nil {
+		return &parser.BlockStmt{Declarations: []parser.Stmt{}}
+	}
+	return stmt
+}
+
+func (o *optimizer) expr(expr parser.Expr) parser.Expr {
+	switch expr := expr.(type) {
+	case *parser.UnaryExpr:
+		expr.Operand = o.expr(expr.Operand)
+		if isLiteral(expr.Operand) {
+			return o.fold(expr, expr.Operator)
+		}
+	case *parser.BinaryExpr:
+		expr.Left = o.expr(expr.Left)
+		expr.Right = o.expr(expr.Right)
+		if isLiteral(expr.Left) && isLiteral(expr.Right) {
+			return o.fold(expr, expr.Operator)
+		}
+		// A constant left operand may decide a logical expression alone
+		if left, ok := o.truthiness(expr.Left); ok {
+			if (expr.Operator.Type == l.OR && left) || (expr.Operator.Type == l.AND && !left) {
+				return literal(interpreter.NewValue(left), expr.Operator)
+			}
+		}
+	case *parser.AssignExpr:
+		expr.Value = o.expr(expr.Value)
+	case *parser.SetExpr:
+		expr.Target = o.expr(expr.Target)
+		expr.Value = o.expr(expr.Value)
+	case *parser.DestructureExpr:
+		expr.Value = o.expr(expr.Value)
+	case *parser.SpreadExpr:
+		expr.Value = o.expr(expr.Value)
+	case *parser.IncrementExpr:
+		expr.Target = o.expr(expr.Target)
+	case *parser.CallExpr:
+		expr.Callee = o.expr(expr.Callee)
+		for j, argument := range expr.Arguments {
+			expr.Arguments[j] = o.expr(argument)
+		}
+		for _, argument := range expr.NamedArguments {
+			argument.Value = o.expr(argument.Value)
+		}
+	case *parser.ListExpr:
+		for j, element := range expr.Elements {
+			expr.Elements[j] = o.expr(element)
+		}
+	case *parser.IndexExpr:
+		expr.Object = o.expr(expr.Object)
+		expr.Index = o.expr(expr.Index)
+	case *parser.SliceExpr:
+		expr.Object = o.expr(expr.Object)
+		expr.Start = o.expr(expr.Start)
+		expr.End = o.expr(expr.End)
+	case *parser.GetExpr:
+		expr.Object = o.expr(expr.Object)
+	case *parser.OptionalChainExpr:
+		expr.Chain = o.expr(expr.Chain)
+	case *parser.ConditionalExpr:
+		expr.Condition = o.expr(expr.Condition)
+		expr.Then = o.expr(expr.Then)
+		expr.Else = o.expr(expr.Else)
+		if condition, ok := o.truthiness(expr.Condition); ok {
+			if condition {
+				return expr.Then
+			}
+			return expr.Else
+		}
+	case *parser.RangeExpr:
+		expr.Start = o.expr(expr.Start)
+		expr.End = o.expr(expr.End)
+		expr.Step = o.expr(expr.Step)
+	case *parser.MapExpr:
+		for j, key := range expr.Keys {
+			expr.Keys[j] = o.expr(key)
+			expr.Values[j] = o.expr(expr.Values[j])
+		}
+	case *parser.InterpolationExpr:
+		constant := true
+		for j, part := range expr.Parts {
+			expr.Parts[j] = o.expr(part)
+			constant = constant && isLiteral(expr.Parts[j])
+		}
+		if constant {
+			return o.fold(expr, expr.Token)
+		}
+	case *parser.FuncExpr:
+		o.stmt(expr.FuncStmt)
+	}
+	return expr
+}
+
+// fold replaces an expression of literals with its value, unless its
+// evaluation fails
+func (o *optimizer) fold(expr parser.Expr, operator *l.Token) parser.Expr {
+	value, err := o.evaluator.Evaluate(expr)
+	if err != nil {
+		return expr
+	}
+	if folded := literal(value, operator); folded != nil {
+		return folded
+	}
+	return expr
+}
+
+// truthiness reports the truthiness of a literal expression
+func (o *optimizer) truthiness(expr parser.Expr) (bool, bool) {
+	if !isLiteral(expr) {
+		return false, false
+	}
+	negated, err := o.evaluator.Evaluate(&parser.UnaryExpr{
+		Operator: &l.Token{Type: l.BANG},
+		Operand:  expr,
+	})
+	if err != nil {
+		return false, false
+	}
+	return !negated.Data.(bool), true
+}
+
+func isLiteral(expr parser.Expr) bool {
+	_, ok := expr.(*parser.PrimaryExpr)
+	return ok
+}
+
+// literal creates the literal expression of a value, located at the line
+// of `token`. It returns nil for values having no literal form.
+func literal(value *interpreter.Value, token *l.Token) *parser.PrimaryExpr {
+	var tokenType l.TokenType
+	literal := value.Data
+	switch data := value.Data.(type) {
+	case float64, int64, *big.Int:
+		tokenType = l.NUMBER
+	case *interpreter.Decimal:
+		tokenType = l.DECIMAL
+		literal = data.String()
+	case string:
+		tokenType = l.STRING
+	case bool:
+		tokenType = l.FALSE
+		if data {
+			tokenType = l.TRUE
+		}
+	case nil:
+		tokenType = l.NIL
+	default:
+		return nil
+	}
+	return &parser.PrimaryExpr{
+		Value: &l.Token{Type: tokenType, Value: literal, Line: token.Line},
+	}
+}
@@ -0,0 +1,95 @@
+package optimizer
+
+import (
+	"testing"
+
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+func parse(t *testing.T, source string) []parser.Stmt {
+	tokens, err := l.NewLexer().Parse(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, errs := parser.NewParser().Parse(tokens)
+	if len(errs) != 0 {
+		t.Fatal(errs[0])
+	}
+	return statements
+}
+
+func TestFoldsConstants(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected any
+	}{
+		{"print 60 * 60 * 24;", int64(86400)},
+		{"print \"a\" + \"b\";", "ab"},
+		{"print -(1 + 1);", int64(-2)},
+		{"print !(1 < 2);", false},
+		{"print false and x;", false},
+		{"print 1 < 2 ? \"yes\" : x;", "yes"},
+		{"print nil ?? 2 ** 3 % 5;", int64(3)},
+		{"print 0.1d + 0.2d == 0.3d;", true},
+		{"print 0.1d + 0.2d;", "0.3"},
+		{"print 2 ** 64 - 2 ** 64;", int64(0)},
+	}
+	for _, test := range tests {
+		statements := Optimize(parse(t, test.source))
+		primary, ok := statements[0].(*parser.PrintStmt).Expr.(*parser.PrimaryExpr)
+		if !ok {
+			t.Errorf("%s: expression was not folded", test.source)
+			continue
+		}
+		if primary.Value.Value != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.source, test.expected, primary.Value.Value)
+		}
+	}
+}
+
+func TestKeepsFailingExpressions(t *testing.T) {
+	statements := Optimize(parse(t, "print 1 / (2 - 2);"))
+	binary, ok := statements[0].(*parser.PrintStmt).Expr.(*parser.BinaryExpr)
+	if !ok {
+		t.Fatal("division by zero was folded")
+	}
+	if _, ok := binary.Right.(*parser.PrimaryExpr); !ok {
+		t.Error("operand of the division was not folded")
+	}
+}
+
+// A power too large to compute fails instead of being folded
+func TestKeepsPowerTooLarge(t *testing.T) {
+	statements := Optimize(parse(t, "print 10 ** (10 ** 9);"))
+	binary, ok := statements[0].(*parser.PrintStmt).Expr.(*parser.BinaryExpr)
+	if !ok {
+		t.Fatal("power was folded")
+	}
+	if _, ok := binary.Right.(*parser.PrimaryExpr); !ok {
+		t.Error("exponent of the power was not folded")
+	}
+}
+
+func TestRemovesDeadCode(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected int
+	}{
+		{"if (false) print 1;", 0},
+		{"if (false) print 1; else print 2;", 1},
+		{"while (nil) print 1;", 0},
+		{"if (1 == 1) print 1;", 1},
+	}
+	for _, test := range tests {
+		if statements := Optimize(parse(t, test.source)); len(statements) != test.expected {
+			t.Errorf("%s: expected %d statements, got %d", test.source, test.expected, len(statements))
+		}
+	}
+
+	statements := Optimize(parse(t, "fun f() { return 1; print 2; }"))
+	body := statements[0].(*parser.FuncStmt).Body
+	if len(body.Declarations) != 1 {
+		t.Errorf("expected unreachable statements to be removed, got %d statements", len(body.Declarations))
+	}
+}
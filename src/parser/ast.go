@@ -17,18 +17,8 @@ type (
 		Call()
 	}
 
-	Loopable interface {
-		SetBreaked(isBreaked bool)
-		IsBreaked() bool
-		SetContinued(isContinued bool)
-		IsContinued() bool
-	}
-
-	Returnable interface {
-		IsReturned() bool
-		SetIsReturned(isReturned bool)
-		// setReturnValue(value *Value)
-		// getReturnValue() *Value
+	Pattern interface {
+		Pattern()
 	}
 )
 
@@ -52,9 +42,53 @@ type (
 		Name *l.Token
 	}
 
+	// AssignExpr assigns a variable, Operator is `=` or a compound
+	// assignment such as `+=`
 	AssignExpr struct {
-		Name  *l.Token
-		Value Expr
+		Name     *l.Token
+		Operator *l.Token
+		Value    Expr
+	}
+
+	// SetExpr assigns the element of an IndexExpr or the property of a
+	// GetExpr target
+	SetExpr struct {
+		Target   Expr
+		Operator *l.Token
+		Value    Expr
+	}
+
+	// DestructureExpr is `[a, b] = value`, assigning the elements of a list
+	// to the targets of the same position. A target is an assignable
+	// expression, a nested list of targets or `_` ignoring its element. A
+	// last SpreadExpr target is assigned the list of remaining elements.
+	DestructureExpr struct {
+		Target *ListExpr
+		Value  Expr
+	}
+
+	// SpreadExpr `...expr` inserts the values of an iterable among the
+	// arguments of a call or the elements of a list, or the entries of a
+	// map among those of a map. In a MapExpr it's a key without value.
+	SpreadExpr struct {
+		Ellipsis *l.Token
+		Value    Expr
+	}
+
+	// IncrementExpr is `++target`, `target--`... evaluating to the new value
+	// when Prefix and to the previous one otherwise
+	IncrementExpr struct {
+		Operator *l.Token
+		Target   Expr
+		Prefix   bool
+	}
+
+	// ConditionalExpr is `condition ? then : else`
+	ConditionalExpr struct {
+		Question  *l.Token
+		Condition Expr
+		Then      Expr
+		Else      Expr
 	}
 
 	FuncExpr struct {
@@ -62,22 +96,85 @@ type (
 	}
 
 	CallExpr struct {
-		Callee    Expr
-		Arguments []Expr
+		Callee         Expr
+		Paren          *l.Token
+		Arguments      []Expr
+		NamedArguments []*NamedArgument
+	}
+
+	ListExpr struct {
+		Bracket  *l.Token
+		Elements []Expr
+	}
+
+	IndexExpr struct {
+		Object  Expr
+		Bracket *l.Token
+		Index   Expr
+	}
+
+	// SliceExpr is `object[start:end]`, where both bounds may be nil
+	SliceExpr struct {
+		Object  Expr
+		Bracket *l.Token
+		Start   Expr
+		End     Expr
+	}
+
+	// GetExpr is `object.name`, or `object?.name` when Optional
+	GetExpr struct {
+		Object   Expr
+		Name     *l.Token
+		Optional bool
+	}
+
+	// OptionalChainExpr is a chain of calls, indexes and properties holding
+	// a `?.`, the whole chain evaluates to nil once a `?.` finds nil
+	OptionalChainExpr struct {
+		Chain Expr
+	}
+
+	// RangeExpr is `start..end step n`, the end being included for `..=`.
+	// Step is nil when omitted.
+	RangeExpr struct {
+		Operator *l.Token
+		Start    Expr
+		End      Expr
+		Step     Expr
+	}
+
+	// MapExpr is a `{key: value}` literal, Keys and Values are parallel
+	MapExpr struct {
+		Brace  *l.Token
+		Keys   []Expr
+		Values []Expr
+	}
+
+	// InterpolationExpr is an interpolated string, its parts are the
+	// string segments and the embedded expressions in source order
+	InterpolationExpr struct {
+		Token *l.Token
+		Parts []Expr
 	}
 )
 
 type (
 	PrintStmt struct {
-		Expr Expr
+		Token *l.Token
+		Expr  Expr
 	}
 
 	ExprStmt struct {
-		Expr Expr
+		Token *l.Token
+		Expr  Expr
 	}
 
+	// VarStmt declares Name, or the names bound by a list or map Pattern
+	// destructuring the initializer, Name then being nil
 	VarStmt struct {
+		Kind       l.TokenType // VAR, LET or CONST
 		Name       *l.Token
+		Pattern    Pattern
 		Initilizer Expr
 	}
 
@@ -86,48 +183,196 @@ type (
 	}
 
 	IfStmt struct {
+		Token     *l.Token
 		Condition Expr
 		ThenStmt  Stmt
 		ElseStmt  Stmt
 	}
 
 	WhileStmt struct {
-		Condition    Expr
-		Body         Stmt
-		_isBreaked   bool
-		_isContinued bool
+		Token     *l.Token
+		Label     *l.Token // nil for an unlabeled loop
+		Condition Expr
+		Body      Stmt
 	}
 
 	ForStmt struct {
+		Token          *l.Token
+		Label          *l.Token
 		Initialization Stmt
 		Condition      Expr
 		Updation       Expr
 		Body           Stmt
-		_isBreaked     bool
-		_isContinued   bool
 	}
 
+	// DoWhileStmt runs its body once before checking its condition
+	DoWhileStmt struct {
+		Token     *l.Token
+		Label     *l.Token
+		Body      Stmt
+		Condition Expr
+	}
+
+	// LoopStmt runs its body until a break, return or error
+	LoopStmt struct {
+		Token *l.Token
+		Label *l.Token
+		Body  Stmt
+	}
+
+	// BreakStmt exits the loop named by Label, or the innermost loop when
+	// Label is nil
 	BreakStmt struct {
 		Token *l.Token
+		Label *l.Token
 	}
 
 	ContinueStmt struct {
 		Token *l.Token
+		Label *l.Token
 	}
 
+	// FuncStmt is a generator when its body, not counting nested
+	// functions, holds a yield statement
 	FuncStmt struct {
 		Name        *l.Token
-		Parameters  []*l.Token
+		Parameters  []*Parameter
 		Body        *BlockStmt
-		_isReturned bool
+		IsGenerator bool
 	}
 
 	ReturnStmt struct {
 		Token *l.Token
 		Expr  Expr
 	}
+
+	// ForInStmt is `for (name in iterable) body`
+	ForInStmt struct {
+		Token    *l.Token
+		Label    *l.Token
+		Name     *l.Token
+		Iterable Expr
+		Body     Stmt
+	}
+
+	// MatchStmt runs the body of the first case having a pattern matching
+	// the subject
+	MatchStmt struct {
+		Token   *l.Token
+		Subject Expr
+		Cases   []*MatchCase
+	}
+
+	YieldStmt struct {
+		Token *l.Token
+		Expr  Expr
+	}
+
+	// SpawnStmt runs a call on its own goroutine
+	SpawnStmt struct {
+		Token *l.Token
+		Call  *CallExpr
+	}
+
+	// SelectStmt waits until one of its channel operations can proceed and
+	// runs the body of its case, or runs Default at once when it's not nil
+	SelectStmt struct {
+		Token   *l.Token
+		Cases   []*SelectCase
+		Default Stmt
+	}
+
+	// TestStmt is a `test "name" { ... }` block, it is only run by the
+	// test runner and skipped on a normal execution
+	TestStmt struct {
+		Token *l.Token
+		Name  string
+		Body  *BlockStmt
+	}
 )
 
+// MatchCase is a `case pattern, pattern => body` of a match statement
+type MatchCase struct {
+	Token    *l.Token
+	Patterns []Pattern
+	Body     Stmt
+}
+
+type (
+	// WildcardPattern `_` matches any value
+	WildcardPattern struct {
+		Token *l.Token
+	}
+
+	// LiteralPattern matches a value equal to its literal
+	LiteralPattern struct {
+		Value *PrimaryExpr
+	}
+
+	// RangePattern `low..high` matches the numbers from low to high, high
+	// being excluded unless the range is written `low..=high`
+	RangePattern struct {
+		Low       *l.Token
+		High      *l.Token
+		Inclusive bool
+	}
+
+	// BindingPattern matches any value and binds it to Name in the case
+	BindingPattern struct {
+		Name *l.Token
+	}
+
+	// ListPattern matches a list element by element. With a `...rest` the
+	// list may be longer and its remaining elements are bound to RestName,
+	// which is nil for an unnamed `...`
+	ListPattern struct {
+		Bracket  *l.Token
+		Elements []Pattern
+		Rest     bool
+		RestName *l.Token
+	}
+
+	// MapPattern matches a map having every key of the pattern with values
+	// matching their pattern, other keys are ignored
+	MapPattern struct {
+		Brace  *l.Token
+		Keys   []*PrimaryExpr
+		Values []Pattern
+	}
+)
+
+func (p *WildcardPattern) Pattern() {}
+func (p *LiteralPattern) Pattern()  {}
+func (p *RangePattern) Pattern()    {}
+func (p *BindingPattern) Pattern()  {}
+func (p *ListPattern) Pattern()     {}
+func (p *MapPattern) Pattern()      {}
+
+// SelectCase is `case channel.send(value) => body`, or for a receive
+// `case channel.recv() => body` where `var name = channel.recv()` binds
+// the received value in the body. Value is nil for a receive.
+type SelectCase struct {
+	Token   *l.Token
+	Channel Expr
+	Value   Expr
+	Name    *l.Token
+	Body    Stmt
+}
+
+// Parameter of a function, `Default` is evaluated at call time when no
+// argument is given and a `Rest` parameter collects the extra arguments
+type Parameter struct {
+	Name    *l.Token
+	Default Expr
+	Rest    bool
+}
+
+// NamedArgument is a `name: value` argument of a call
+type NamedArgument struct {
+	Name  *l.Token
+	Value Expr
+}
+
 func (t *PrintStmt) Stmt()    {}
 func (t *ExprStmt) Stmt()     {}
 func (t *VarStmt) Stmt()      {}
@@ -136,30 +381,17 @@ func (t *IfStmt) Stmt()       {}
 func (t *BreakStmt) Stmt()    {}
 func (t *ContinueStmt) Stmt() {}
 func (t *ReturnStmt) Stmt()   {}
-
-func (t *WhileStmt) Stmt()                         {}
-func (t *WhileStmt) IsBreaked() bool               { return t._isBreaked }
-func (t *WhileStmt) SetBreaked(isBreaked bool)     { t._isBreaked = isBreaked }
-func (t *WhileStmt) IsContinued() bool             { return t._isContinued }
-func (t *WhileStmt) SetContinued(isContinued bool) { t._isContinued = isContinued }
-
-func (t *ForStmt) Stmt()                         {}
-func (t *ForStmt) IsBreaked() bool               { return t._isBreaked }
-func (t *ForStmt) SetBreaked(isBreaked bool)     { t._isBreaked = isBreaked }
-func (t *ForStmt) IsContinued() bool             { return t._isContinued }
-func (t *ForStmt) SetContinued(isContinued bool) { t._isContinued = isContinued }
-
-func (t *FuncStmt) Stmt()                         {}
-func (t *FuncStmt) IsReturned() bool              { return t._isReturned }
-func (t *FuncStmt) SetIsReturned(isReturned bool) { t._isReturned = isReturned }
-
-// func (t *FuncStmt) setReturnValue(value *Value) {
-// 	t.returnValue = value
-// }
-
-// func (t *FuncStmt) getReturnValue() *Value {
-// 	return t.returnValue
-// }
+func (t *YieldStmt) Stmt()    {}
+func (t *TestStmt) Stmt()     {}
+func (t *MatchStmt) Stmt()    {}
+func (t *WhileStmt) Stmt()    {}
+func (t *DoWhileStmt) Stmt()  {}
+func (t *LoopStmt) Stmt()     {}
+func (t *ForStmt) Stmt()      {}
+func (t *ForInStmt) Stmt()    {}
+func (t *FuncStmt) Stmt()     {}
+func (t *SpawnStmt) Stmt()    {}
+func (t *SelectStmt) Stmt()   {}
 
 func (e *PrimaryExpr) Expr()  {}
 func (e *UnaryExpr) Expr()    {}
@@ -168,3 +400,20 @@ func (e *VariableExpr) Expr() {}
 func (e *AssignExpr) Expr()   {}
 func (e *FuncExpr) Expr()     {}
 func (e *CallExpr) Expr()     {}
+func (e *ListExpr) Expr()     {}
+func (e *IndexExpr) Expr()    {}
+func (e *SliceExpr) Expr()    {}
+func (e *GetExpr) Expr()      {}
+func (e *MapExpr) Expr()      {}
+func (e *RangeExpr) Expr()    {}
+func (e *SetExpr) Expr()      {}
+
+func (e *DestructureExpr) Expr() {}
+func (e *SpreadExpr) Expr()      {}
+
+func (e *IncrementExpr) Expr() {}
+
+func (e *ConditionalExpr) Expr()   {}
+func (e *OptionalChainExpr) Expr() {}
+
+func (e *InterpolationExpr) Expr() {}
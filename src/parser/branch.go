@@ -1,16 +1,51 @@
 package parser
 
+import l "github.com/debugg-er/lox/src/lexer"
+
 type context struct {
 	inFor      bool
 	inWhile    bool
 	inFunction bool
+	labels     []string // Labels of the enclosing loops of the function
+}
+
+// loop returns the context of the body of a loop labeled `label`
+func (c *context) loop(label *l.Token) (*context, error) {
+	inner := *c
+	if label == nil {
+		return &inner, nil
+	}
+	name := label.Value.(string)
+	if c.hasLabel(name) {
+		return nil, NewParserError(label, "SyntaxError: Label '"+name+"' is already used by an enclosing loop")
+	}
+	inner.labels = append(c.labels[:len(c.labels):len(c.labels)], name)
+	return &inner, nil
+}
+
+func (c *context) hasLabel(name string) bool {
+	for _, label := range c.labels {
+		if label == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLabel reports the label of a break or continue which names no
+// enclosing loop
+func (c *context) checkLabel(label *l.Token) []error {
+	if label != nil && !c.hasLabel(label.Value.(string)) {
+		return []error{NewParserError(label, "SyntaxError: Undefined label '"+label.Value.(string)+"'")}
+	}
+	return nil
 }
 
 func verifyBranching(stmt Stmt) []error {
 	context := &context{}
 
 	switch stmt.(type) {
-	case *BlockStmt, *IfStmt, *ForStmt, *WhileStmt:
+	case *YieldStmt, *SelectStmt, *BlockStmt, *IfStmt, *ForStmt, *ForInStmt, *WhileStmt, *DoWhileStmt, *LoopStmt, *FuncStmt, *TestStmt, *MatchStmt:
 		return _verifyBranching(stmt, context)
 	default:
 		return nil
@@ -23,22 +58,63 @@ func _verifyBranching(stmt Stmt, context *context) []error {
 		if !context.inFor && !context.inWhile {
 			return []error{NewParserError(stmt.Token, "SyntaxError: 'break' statement can only be used within an enclosing iteration")}
 		}
+		return context.checkLabel(stmt.Label)
 	case *ContinueStmt:
 		if !context.inFor && !context.inWhile {
 			return []error{NewParserError(stmt.Token, "SyntaxError: 'continue' statement can only be used within an enclosing iteration")}
 		}
+		return context.checkLabel(stmt.Label)
 	case *ReturnStmt:
 		if !context.inFunction {
 			return []error{NewParserError(stmt.Token, "SyntaxError: 'return' statement can only be used within function")}
 		}
+	case *YieldStmt:
+		if !context.inFunction {
+			return []error{NewParserError(stmt.Token, "SyntaxError: 'yield' statement can only be used within function")}
+		}
+	// The context of a loop or function only applies to its body, loops
+	// don't extend into the functions declared within them
 	case *ForStmt:
-		context.inFor = true
-		return _verifyBranching(stmt.Body, context)
+		inner, err := context.loop(stmt.Label)
+		if err != nil {
+			return []error{err}
+		}
+		inner.inFor = true
+		return _verifyBranching(stmt.Body, inner)
+	case *ForInStmt:
+		inner, err := context.loop(stmt.Label)
+		if err != nil {
+			return []error{err}
+		}
+		inner.inFor = true
+		return _verifyBranching(stmt.Body, inner)
 	case *WhileStmt:
-		context.inWhile = true
-		return _verifyBranching(stmt.Body, context)
+		inner, err := context.loop(stmt.Label)
+		if err != nil {
+			return []error{err}
+		}
+		inner.inWhile = true
+		return _verifyBranching(stmt.Body, inner)
+	case *DoWhileStmt:
+		inner, err := context.loop(stmt.Label)
+		if err != nil {
+			return []error{err}
+		}
+		inner.inWhile = true
+		return _verifyBranching(stmt.Body, inner)
+	case *LoopStmt:
+		inner, err := context.loop(stmt.Label)
+		if err != nil {
+			return []error{err}
+		}
+		inner.inWhile = true
+		return _verifyBranching(stmt.Body, inner)
 	case *FuncStmt:
-		context.inFunction = true
+		inner := *context
+		inner.inFor, inner.inWhile, inner.inFunction = false, false, true
+		inner.labels = nil
+		return _verifyBranching(stmt.Body, &inner)
+	case *TestStmt:
 		return _verifyBranching(stmt.Body, context)
 	case *IfStmt:
 		errors := append(
@@ -49,6 +125,24 @@ func _verifyBranching(stmt Stmt, context *context) []error {
 			return nil
 		}
 		return errors
+	case *MatchStmt:
+		errors := make([]error, 0)
+		for _, matchCase := range stmt.Cases {
+			errors = append(errors, _verifyBranching(matchCase.Body, context)...)
+		}
+		if len(errors) == 0 {
+			return nil
+		}
+		return errors
+	case *SelectStmt:
+		errors := _verifyBranching(stmt.Default, context)
+		for _, selectCase := range stmt.Cases {
+			errors = append(errors, _verifyBranching(selectCase.Body, context)...)
+		}
+		if len(errors) == 0 {
+			return nil
+		}
+		return errors
 	case *BlockStmt:
 		errors := make([]error, 0)
 		for _, childStmt := range stmt.Declarations {
@@ -0,0 +1,110 @@
+package parser
+
+import l "github.com/debugg-er/lox/src/lexer"
+
+// destructuringDecl parses `[a, b] = value;` or `{name, age} = value;`
+// following `var`, `let` or `const`
+func (p *Parser) destructuringDecl(kind l.TokenType) (Stmt, error) {
+	pattern, err := p.destructuringPattern(make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	if err := p.consume(l.EQUAL, "Expected '=' after destructuring pattern."); err != nil {
+		return nil, err
+	}
+	initilizer, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.consume(l.SEMICOLON, "Expected ';' after expression"); err != nil {
+		return nil, err
+	}
+	for _, name := range Bindings(pattern) {
+		p.declare(name, kind)
+	}
+	return &VarStmt{kind, nil, pattern, initilizer}, nil
+}
+
+// destructuringPattern parses a pattern of a declaration, which binds names
+// without testing values: literals and ranges aren't allowed
+func (p *Parser) destructuringPattern(bindings map[string]bool) (Pattern, error) {
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer p.unnest()
+
+	switch token := p.peek(); token.Type {
+	case l.IDENTIFIER:
+		return p.binding(p.advance(), bindings)
+	case l.LEFT_BRACKET:
+		return p.listPattern(bindings, p.destructuringPattern)
+	case l.LEFT_BRACE:
+		return p.mapPattern(bindings, p.destructuringPattern)
+	default:
+		return nil, NewParserError(token, "Expected name or destructuring pattern.")
+	}
+}
+
+// destructuringAssignment parses the value assigned to a list of targets,
+// whose `=` was consumed
+func (p *Parser) destructuringAssignment(target *ListExpr) (Expr, error) {
+	if err := p.checkTargets(target); err != nil {
+		return nil, err
+	}
+	value, err := p.assignment()
+	if err != nil {
+		return nil, err
+	}
+	return &DestructureExpr{target, value}, nil
+}
+
+// checkTargets reports the elements of a destructuring assignment which
+// aren't assignable. The last one may be a `...rest` target.
+func (p *Parser) checkTargets(target *ListExpr) error {
+	for j, element := range target.Elements {
+		if spread, ok := element.(*SpreadExpr); ok {
+			if j != len(target.Elements)-1 {
+				return NewParserError(spread.Ellipsis, "Rest target must be the last target.")
+			}
+			element = spread.Value
+		}
+		if list, ok := element.(*ListExpr); ok {
+			if err := p.checkTargets(list); err != nil {
+				return err
+			}
+			continue
+		}
+		if !isAssignable(element) {
+			return NewParserError(target.Bracket, "Invalid assignment target.")
+		}
+		if variable, ok := element.(*VariableExpr); ok && variable.Name.Value != "_" {
+			p.checkConstant(variable, variable.Name)
+		}
+	}
+	return nil
+}
+
+// Bindings returns the names bound by a pattern
+func Bindings(pattern Pattern) []*l.Token {
+	switch pattern := pattern.(type) {
+	case *BindingPattern:
+		return []*l.Token{pattern.Name}
+	case *ListPattern:
+		names := make([]*l.Token, 0)
+		for _, element := range pattern.Elements {
+			names = append(names, Bindings(element)...)
+		}
+		if pattern.RestName != nil {
+			names = append(names, pattern.RestName)
+		}
+		return names
+	case *MapPattern:
+		names := make([]*l.Token, 0)
+		for _, value := range pattern.Values {
+			names = append(names, Bindings(value)...)
+		}
+		return names
+	default:
+		return nil
+	}
+}
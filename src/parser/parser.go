@@ -1,18 +1,31 @@
 package parser
 
 import (
+	"math/big"
+
 	l "github.com/debugg-er/lox/src/lexer"
 )
 
+// maxNesting bounds the depth of nested statements and expressions so a
+// pathological input can't overflow the stack of the recursive descent
+const maxNesting = 512
+
 type Parser struct {
 	current int
+	depth   int
 	tokens  []l.Token
+	scope   *scope
+	// Errors found without stopping the parsing, drained by Parse
+	errors []error
+	// Whether each function body being parsed holds a yield, innermost last
+	generators []bool
 }
 
 func NewParser() *Parser {
 	return &Parser{
 		current: 0,
 		tokens:  make([]l.Token, 0),
+		scope:   newScope(nil),
 	}
 }
 
@@ -22,6 +35,8 @@ func (p *Parser) Parse(tokens []l.Token) ([]Stmt, []error) {
 	errors := make([]error, 0)
 	for !p.isAtEnd() {
 		stmt, err := p.declaration()
+		errors = append(errors, p.errors...)
+		p.errors = nil
 		if err != nil {
 			errors = append(errors, err)
 			p.synchronize()
@@ -40,13 +55,53 @@ func (p *Parser) Parse(tokens []l.Token) ([]Stmt, []error) {
 }
 
 func (p *Parser) declaration() (Stmt, error) {
-	if p.match(l.VAR) != nil {
-		return p.varDecl()
+	if keyword := p.match(l.VAR, l.LET, l.CONST); keyword != nil {
+		return p.varDecl(keyword.Type)
+	}
+	if p.peek().Type == l.FUN && p.peekNext().Type == l.IDENTIFIER {
+		p.advance()
+		return p.funDecl()
+	}
+	// `test` is not reserved, it only starts a test block when followed by its name
+	if p.peek().Type == l.IDENTIFIER && p.peek().Value == "test" && p.peekNext().Type == l.STRING {
+		return p.testDecl()
 	}
 	return p.statement()
 }
 
-func (p *Parser) varDecl() (Stmt, error) {
+func (p *Parser) funDecl() (Stmt, error) {
+	name := p.advance()
+	if err := p.consume(l.LEFT_PAREN, "Expect '(' after function name."); err != nil {
+		return nil, err
+	}
+	// Declared before its body for recursive calls
+	p.declare(name, l.FUN)
+	return p.function(name)
+}
+
+func (p *Parser) testDecl() (Stmt, error) {
+	testToken := p.advance()
+	name := p.advance()
+	if err := p.consume(l.LEFT_BRACE, "Expected '{' after test name"); err != nil {
+		return nil, err
+	}
+	body, err := p.blockStmt()
+	if err != nil {
+		return nil, err
+	}
+	return &TestStmt{
+		Token: testToken,
+		Name:  name.Value.(string),
+		Body:  body.(*BlockStmt),
+	}, nil
+}
+
+// varDecl parses the declaration following `var`, `let` or `const`, its
+// names are declared after its initializer which can't refer to them
+func (p *Parser) varDecl(kind l.TokenType) (Stmt, error) {
+	if next := p.peek().Type; next == l.LEFT_BRACKET || next == l.LEFT_BRACE {
+		return p.destructuringDecl(kind)
+	}
 	token := p.advance()
 	if token.Type != l.IDENTIFIER {
 		return nil, NewParserError(token, "Expected variable name.")
@@ -58,14 +113,22 @@ func (p *Parser) varDecl() (Stmt, error) {
 			return nil, err
 		}
 		initilizer = expr
+	} else if kind == l.CONST {
+		return nil, NewParserError(token, "Constant '"+token.Value.(string)+"' must be initialized.")
 	}
 	if err := p.consume(l.SEMICOLON, "Expected ';' after expression"); err != nil {
 		return nil, err
 	}
-	return &VarStmt{token, initilizer}, nil
+	p.declare(token, kind)
+	return &VarStmt{kind, token, nil, initilizer}, nil
 }
 
 func (p *Parser) statement() (Stmt, error) {
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer p.unnest()
+
 	if p.match(l.PRINT) != nil {
 		return p.printStmt()
 	}
@@ -81,6 +144,9 @@ func (p *Parser) statement() (Stmt, error) {
 	if p.match(l.FOR) != nil {
 		return p.forStmt()
 	}
+	if p.match(l.MATCH) != nil {
+		return p.matchStmt()
+	}
 	if p.match(l.BREAK) != nil {
 		return p.breakStmt()
 	}
@@ -90,59 +156,303 @@ func (p *Parser) statement() (Stmt, error) {
 	if p.match(l.RETURN) != nil {
 		return p.returnStmt()
 	}
+	if p.match(l.YIELD) != nil {
+		return p.yieldStmt()
+	}
+	if p.match(l.SPAWN) != nil {
+		return p.spawnStmt()
+	}
+	if p.match(l.SELECT) != nil {
+		return p.selectStmt()
+	}
+	// `do` and `loop` are not reserved, they only start a loop when
+	// followed by its body
+	if p.peek().Type == l.IDENTIFIER && p.peekNext().Type == l.LEFT_BRACE {
+		switch p.peek().Value {
+		case "do":
+			return p.doWhileStmt()
+		case "loop":
+			return p.loopStmt()
+		}
+	}
+	if p.peek().Type == l.IDENTIFIER && p.peekNext().Type == l.COLON {
+		return p.labeledStmt()
+	}
 	return p.exprStmt()
 }
 
 func (p *Parser) returnStmt() (Stmt, error) {
 	returnToken := p.previous()
+	if p.match(l.SEMICOLON) != nil {
+		return &ReturnStmt{returnToken, nil}, nil
+	}
 	expr, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
+	// `return a, b;` returns the tuple [a, b]
+	if p.peek().Type == l.COMMA {
+		values := []Expr{expr}
+		for p.match(l.COMMA) != nil {
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		expr = &ListExpr{returnToken, values}
+	}
 	if err := p.consume(l.SEMICOLON, "Expected ';' after return"); err != nil {
 		return nil, err
 	}
 	return &ReturnStmt{returnToken, expr}, nil
 }
 
+// yieldStmt marks the enclosing function as a generator, a yield outside of
+// a function is reported by verifyBranching
+func (p *Parser) yieldStmt() (Stmt, error) {
+	yieldToken := p.previous()
+	if depth := len(p.generators); depth != 0 {
+		p.generators[depth-1] = true
+	}
+	if p.match(l.SEMICOLON) != nil {
+		return &YieldStmt{yieldToken, nil}, nil
+	}
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.consume(l.SEMICOLON, "Expected ';' after yield"); err != nil {
+		return nil, err
+	}
+	return &YieldStmt{yieldToken, expr}, nil
+}
+
+func (p *Parser) spawnStmt() (Stmt, error) {
+	spawnToken := p.previous()
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	call, ok := expr.(*CallExpr)
+	if !ok {
+		return nil, NewParserError(spawnToken, "Expected a function call after 'spawn'.")
+	}
+	if err := p.consume(l.SEMICOLON, "Expected ';' after spawn"); err != nil {
+		return nil, err
+	}
+	return &SpawnStmt{spawnToken, call}, nil
+}
+
+func (p *Parser) selectStmt() (Stmt, error) {
+	selectStmt := &SelectStmt{Token: p.previous(), Cases: make([]*SelectCase, 0)}
+	if err := p.consume(l.LEFT_BRACE, "Expected '{' after select"); err != nil {
+		return nil, err
+	}
+	for p.peek().Type != l.RIGHT_BRACE && !p.isAtEnd() {
+		// `default` is only a keyword in a select
+		if p.peek().Type == l.IDENTIFIER && p.peek().Value == "default" {
+			token := p.advance()
+			if selectStmt.Default != nil {
+				return nil, NewParserError(token, "Select can't have more than one default case.")
+			}
+			if err := p.consume(l.ARROW, "Expected '=>' after default"); err != nil {
+				return nil, err
+			}
+			body, err := p.statement()
+			if err != nil {
+				return nil, err
+			}
+			selectStmt.Default = body
+			continue
+		}
+		selectCase, err := p.selectCase()
+		if err != nil {
+			return nil, err
+		}
+		selectStmt.Cases = append(selectStmt.Cases, selectCase)
+	}
+	if err := p.consume(l.RIGHT_BRACE, "Expected '}' after select cases"); err != nil {
+		return nil, err
+	}
+	return selectStmt, nil
+}
+
+func (p *Parser) selectCase() (*SelectCase, error) {
+	if err := p.consume(l.CASE, "Expected 'case' or 'default'."); err != nil {
+		return nil, err
+	}
+	selectCase := &SelectCase{Token: p.previous()}
+	if p.match(l.VAR) != nil {
+		if err := p.consume(l.IDENTIFIER, "Expected variable name."); err != nil {
+			return nil, err
+		}
+		selectCase.Name = p.previous()
+		if err := p.consume(l.EQUAL, "Expected '=' after variable name."); err != nil {
+			return nil, err
+		}
+	}
+	operation, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	// The operation is a call of the send or recv method of a channel
+	call, ok := operation.(*CallExpr)
+	var method *GetExpr
+	if ok {
+		method, ok = call.Callee.(*GetExpr)
+	}
+	// The value sent is a single expression
+	var spread bool
+	if ok && len(call.Arguments) == 1 {
+		_, spread = call.Arguments[0].(*SpreadExpr)
+	}
+	switch {
+	case ok && method.Name.Value == "recv" && len(call.Arguments) == 0 && len(call.NamedArguments) == 0:
+	case ok && method.Name.Value == "send" && len(call.Arguments) == 1 && !spread && len(call.NamedArguments) == 0 && selectCase.Name == nil:
+		selectCase.Value = call.Arguments[0]
+	default:
+		return nil, NewParserError(selectCase.Token, "Expected 'channel.recv()' or 'channel.send(value)' in select case.")
+	}
+	selectCase.Channel = method.Object
+
+	if err := p.consume(l.ARROW, "Expected '=>' after select case"); err != nil {
+		return nil, err
+	}
+	if selectCase.Name != nil {
+		p.beginScope()
+		defer p.endScope()
+		p.declare(selectCase.Name, l.VAR)
+	}
+	if selectCase.Body, err = p.statement(); err != nil {
+		return nil, err
+	}
+	return selectCase, nil
+}
+
 func (p *Parser) continueStmt() (Stmt, error) {
+	label := p.match(l.IDENTIFIER)
 	if err := p.consume(l.SEMICOLON, "Expected ';' after continue"); err != nil {
 		return nil, err
 	}
-	return &ContinueStmt{p.previous()}, nil
+	return &ContinueStmt{p.previous(), label}, nil
 }
 
 func (p *Parser) breakStmt() (Stmt, error) {
+	label := p.match(l.IDENTIFIER)
 	if err := p.consume(l.SEMICOLON, "Expected ';' after break"); err != nil {
 		return nil, err
 	}
-	return &BreakStmt{p.previous()}, nil
+	return &BreakStmt{p.previous(), label}, nil
+}
+
+// labeledStmt parses a loop preceded by `label:`, which a break or a
+// continue of its body can name
+func (p *Parser) labeledStmt() (Stmt, error) {
+	label := p.advance()
+	p.advance()
+	// The loop keeps its first label, so its break and continue statements
+	// don't report it as undefined
+	for p.peek().Type == l.IDENTIFIER && p.peekNext().Type == l.COLON {
+		extra := p.advance()
+		p.advance()
+		p.errors = append(p.errors, NewParserError(extra, "A loop can only have one label, '"+extra.Value.(string)+"' follows label '"+label.Value.(string)+"'."))
+	}
+	loop, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	switch loop := loop.(type) {
+	case *WhileStmt:
+		loop.Label = label
+	case *ForStmt:
+		loop.Label = label
+	case *ForInStmt:
+		loop.Label = label
+	case *DoWhileStmt:
+		loop.Label = label
+	case *LoopStmt:
+		loop.Label = label
+	default:
+		return nil, NewParserError(label, "Expected a loop after label '"+label.Value.(string)+"'.")
+	}
+	return loop, nil
+}
+
+// doWhileStmt parses `do { body } while (condition);`, whose body runs
+// before the condition is checked
+func (p *Parser) doWhileStmt() (Stmt, error) {
+	doToken := p.advance()
+	p.advance()
+	body, err := p.blockStmt()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.consume(l.WHILE, "Expected 'while' after do body"); err != nil {
+		return nil, err
+	}
+	if err := p.consume(l.LEFT_PAREN, "Expected '(' after while"); err != nil {
+		return nil, err
+	}
+	condition, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.consume(l.RIGHT_PAREN, "Expected ')' after condition"); err != nil {
+		return nil, err
+	}
+	if err := p.consume(l.SEMICOLON, "Expected ';' after do while"); err != nil {
+		return nil, err
+	}
+	return &DoWhileStmt{Token: doToken, Body: body, Condition: condition}, nil
+}
+
+// loopStmt parses `loop { body }`, which runs until a break
+func (p *Parser) loopStmt() (Stmt, error) {
+	loopToken := p.advance()
+	p.advance()
+	body, err := p.blockStmt()
+	if err != nil {
+		return nil, err
+	}
+	return &LoopStmt{Token: loopToken, Body: body}, nil
 }
 
 func (p *Parser) forStmt() (Stmt, error) {
+	forToken := p.previous()
 	if err := p.consume(l.LEFT_PAREN, "Expected '(' after for"); err != nil {
 		return nil, err
 	}
+	if p.peek().Type == l.IDENTIFIER && p.peekNext().Type == l.IN {
+		return p.forInStmt(forToken)
+	}
+	// The initialization is scoped to the loop
+	p.beginScope()
+	defer p.endScope()
 	var initialization Stmt = nil
 	var err error = nil
-	if p.match(l.VAR) != nil {
-		initialization, err = p.varDecl()
+	if keyword := p.match(l.VAR, l.LET, l.CONST); keyword != nil {
+		initialization, err = p.varDecl(keyword.Type)
 	} else {
 		initialization, err = p.exprStmt()
 	}
 	if err != nil {
 		return nil, err
 	}
-	condition, err := p.expression()
-	if err != nil {
-		return nil, err
+	// Without condition the loop runs until a break
+	var condition, updation Expr
+	if p.peek().Type != l.SEMICOLON {
+		if condition, err = p.expression(); err != nil {
+			return nil, err
+		}
 	}
 	if err := p.consume(l.SEMICOLON, "Expected ';' after condition"); err != nil {
 		return nil, err
 	}
-	updation, err := p.expression()
-	if err != nil {
-		return nil, err
+	if p.peek().Type != l.RIGHT_PAREN {
+		if updation, err = p.expression(); err != nil {
+			return nil, err
+		}
 	}
 	if err := p.consume(l.RIGHT_PAREN, "Expected ')' after updation"); err != nil {
 		return nil, err
@@ -152,6 +462,7 @@ func (p *Parser) forStmt() (Stmt, error) {
 		return nil, err
 	}
 	return &ForStmt{
+		Token:          forToken,
 		Initialization: initialization,
 		Condition:      condition,
 		Updation:       updation,
@@ -159,7 +470,33 @@ func (p *Parser) forStmt() (Stmt, error) {
 	}, nil
 }
 
+func (p *Parser) forInStmt(forToken *l.Token) (Stmt, error) {
+	name := p.advance()
+	p.advance()
+	iterable, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.consume(l.RIGHT_PAREN, "Expected ')' after iterable"); err != nil {
+		return nil, err
+	}
+	p.beginScope()
+	defer p.endScope()
+	p.declare(name, l.VAR)
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	return &ForInStmt{
+		Token:    forToken,
+		Name:     name,
+		Iterable: iterable,
+		Body:     body,
+	}, nil
+}
+
 func (p *Parser) whileStmt() (Stmt, error) {
+	whileToken := p.previous()
 	if err := p.consume(l.LEFT_PAREN, "Expected '(' after while"); err != nil {
 		return nil, err
 	}
@@ -175,12 +512,14 @@ func (p *Parser) whileStmt() (Stmt, error) {
 		return nil, err
 	}
 	return &WhileStmt{
+		Token:     whileToken,
 		Condition: expr,
 		Body:      body,
 	}, nil
 }
 
 func (p *Parser) ifStmt() (Stmt, error) {
+	ifToken := p.previous()
 	if err := p.consume(l.LEFT_PAREN, "Expected '(' after if"); err != nil {
 		return nil, err
 	}
@@ -203,20 +542,227 @@ func (p *Parser) ifStmt() (Stmt, error) {
 		}
 	}
 	return &IfStmt{
+		Token:     ifToken,
 		Condition: expr,
 		ThenStmt:  thenStmt,
 		ElseStmt:  elseStmt,
 	}, nil
 }
 
+func (p *Parser) matchStmt() (Stmt, error) {
+	matchToken := p.previous()
+	if err := p.consume(l.LEFT_PAREN, "Expected '(' after match"); err != nil {
+		return nil, err
+	}
+	subject, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.consume(l.RIGHT_PAREN, "Expected ')' after match subject"); err != nil {
+		return nil, err
+	}
+	if err := p.consume(l.LEFT_BRACE, "Expected '{' before match cases"); err != nil {
+		return nil, err
+	}
+	cases := make([]*MatchCase, 0)
+	for p.peek().Type != l.RIGHT_BRACE && !p.isAtEnd() {
+		matchCase, err := p.matchCase()
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, matchCase)
+	}
+	if err := p.consume(l.RIGHT_BRACE, "Expected '}' after match cases"); err != nil {
+		return nil, err
+	}
+	return &MatchStmt{matchToken, subject, cases}, nil
+}
+
+// matchCase parses a case, the names bound by its patterns are scoped to
+// its body
+func (p *Parser) matchCase() (*MatchCase, error) {
+	if err := p.consume(l.CASE, "Expected 'case'."); err != nil {
+		return nil, err
+	}
+	p.beginScope()
+	defer p.endScope()
+	matchCase := &MatchCase{Token: p.previous(), Patterns: make([]Pattern, 0)}
+	for {
+		pattern, err := p.pattern(make(map[string]bool))
+		if err != nil {
+			return nil, err
+		}
+		matchCase.Patterns = append(matchCase.Patterns, pattern)
+		for _, name := range Bindings(pattern) {
+			p.declare(name, l.VAR)
+		}
+		if p.match(l.COMMA) == nil {
+			break
+		}
+	}
+	if err := p.consume(l.ARROW, "Expected '=>' after case patterns"); err != nil {
+		return nil, err
+	}
+	var err error
+	if matchCase.Body, err = p.statement(); err != nil {
+		return nil, err
+	}
+	return matchCase, nil
+}
+
+// pattern parses a case pattern, `bindings` holds the names already bound
+// by the enclosing pattern
+func (p *Parser) pattern(bindings map[string]bool) (Pattern, error) {
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer p.unnest()
+
+	token := p.peek()
+	switch token.Type {
+	case l.IDENTIFIER:
+		return p.binding(p.advance(), bindings)
+	case l.NUMBER, l.DECIMAL, l.MINUS:
+		low, err := p.patternNumber()
+		if err != nil {
+			return nil, err
+		}
+		if p.match(l.DOT_DOT, l.DOT_DOT_EQUAL) == nil {
+			return &LiteralPattern{&PrimaryExpr{low}}, nil
+		}
+		inclusive := p.previous().Type == l.DOT_DOT_EQUAL
+		high, err := p.patternNumber()
+		if err != nil {
+			return nil, err
+		}
+		return &RangePattern{low, high, inclusive}, nil
+	case l.STRING, l.TRUE, l.FALSE, l.NIL:
+		return &LiteralPattern{&PrimaryExpr{p.advance()}}, nil
+	case l.LEFT_BRACKET:
+		return p.listPattern(bindings, p.pattern)
+	case l.LEFT_BRACE:
+		return p.mapPattern(bindings, p.pattern)
+	default:
+		return nil, NewParserError(token, "Expected pattern.")
+	}
+}
+
+// patternNumber parses a number literal of a pattern, optionally negated
+func (p *Parser) patternNumber() (*l.Token, error) {
+	negated := p.match(l.MINUS) != nil
+	number := p.match(l.NUMBER, l.DECIMAL)
+	if number == nil {
+		return nil, NewParserError(p.peek(), "Expected number in pattern.")
+	}
+	if negated {
+		negative := &l.Token{Type: number.Type, Line: number.Line}
+		switch value := number.Value.(type) {
+		case int64:
+			negative.Value = -value
+		case float64:
+			negative.Value = -value
+		case *big.Int:
+			negative.Value = new(big.Int).Neg(value)
+		case string:
+			negative.Value = "-" + value
+		}
+		return negative, nil
+	}
+	return number, nil
+}
+
+// binding returns the pattern binding `name`, or the wildcard for `_`
+func (p *Parser) binding(name *l.Token, bindings map[string]bool) (Pattern, error) {
+	if name.Value == "_" {
+		return &WildcardPattern{name}, nil
+	}
+	if bindings[name.Value.(string)] {
+		return nil, NewParserError(name, "Duplicate binding '"+name.Value.(string)+"' in pattern.")
+	}
+	bindings[name.Value.(string)] = true
+	return &BindingPattern{name}, nil
+}
+
+// listPattern parses a list pattern whose elements are parsed by `element`
+func (p *Parser) listPattern(bindings map[string]bool, element func(map[string]bool) (Pattern, error)) (Pattern, error) {
+	pattern := &ListPattern{Bracket: p.advance(), Elements: make([]Pattern, 0)}
+	for p.peek().Type != l.RIGHT_BRACKET {
+		if p.match(l.ELLIPSIS) != nil {
+			pattern.Rest = true
+			if name := p.match(l.IDENTIFIER); name != nil && name.Value != "_" {
+				if bindings[name.Value.(string)] {
+					return nil, NewParserError(name, "Duplicate binding '"+name.Value.(string)+"' in pattern.")
+				}
+				bindings[name.Value.(string)] = true
+				pattern.RestName = name
+			}
+			p.match(l.COMMA)
+			break
+		}
+		element, err := element(bindings)
+		if err != nil {
+			return nil, err
+		}
+		pattern.Elements = append(pattern.Elements, element)
+		if p.match(l.COMMA) == nil {
+			break
+		}
+	}
+	if err := p.consume(l.RIGHT_BRACKET, "Expect ']' after list pattern."); err != nil {
+		return nil, err
+	}
+	return pattern, nil
+}
+
+// mapPattern parses a map pattern whose values are parsed by `element`. A
+// name alone, as in `{name, age: years}`, is the pattern binding the entry
+// of the same name.
+func (p *Parser) mapPattern(bindings map[string]bool, element func(map[string]bool) (Pattern, error)) (Pattern, error) {
+	pattern := &MapPattern{Brace: p.advance(), Keys: make([]*PrimaryExpr, 0), Values: make([]Pattern, 0)}
+	for p.peek().Type != l.RIGHT_BRACE {
+		key := p.advance()
+		switch key.Type {
+		case l.IDENTIFIER, l.STRING, l.NUMBER, l.DECIMAL, l.TRUE, l.FALSE, l.NIL:
+		default:
+			return nil, NewParserError(key, "Expected key in map pattern.")
+		}
+		var value Pattern
+		var err error
+		if key.Type == l.IDENTIFIER && key.Value != "_" && p.peek().Type != l.COLON {
+			value, err = p.binding(key, bindings)
+		} else if err = p.consume(l.COLON, "Expect ':' after key."); err == nil {
+			value, err = element(bindings)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if key.Type == l.IDENTIFIER {
+			key = &l.Token{Type: l.STRING, Value: key.Value, Line: key.Line}
+		}
+		pattern.Keys = append(pattern.Keys, &PrimaryExpr{key})
+		pattern.Values = append(pattern.Values, value)
+		if p.match(l.COMMA) == nil {
+			break
+		}
+	}
+	if err := p.consume(l.RIGHT_BRACE, "Expect '}' after map pattern."); err != nil {
+		return nil, err
+	}
+	return pattern, nil
+}
+
 func (p *Parser) blockStmt() (Stmt, error) {
+	p.beginScope()
+	defer p.endScope()
 	declarations := make([]Stmt, 0)
 	for !p.isAtEnd() && p.peek().Type != l.RIGHT_BRACE {
 		declaration, err := p.declaration()
 		if err != nil {
 			return nil, err
 		}
-		declarations = append(declarations, declaration)
+		if declaration != nil {
+			declarations = append(declarations, declaration)
+		}
 	}
 	if err := p.consume(l.RIGHT_BRACE, "Expected '}' after block"); err != nil {
 		return nil, err
@@ -227,6 +773,7 @@ func (p *Parser) blockStmt() (Stmt, error) {
 }
 
 func (p *Parser) printStmt() (Stmt, error) {
+	printToken := p.previous()
 	expr, err := p.expression()
 	if err != nil {
 		return nil, err
@@ -234,50 +781,92 @@ func (p *Parser) printStmt() (Stmt, error) {
 	if err = p.consume(l.SEMICOLON, "Expected ';' after value"); err != nil {
 		return nil, err
 	}
-	return &PrintStmt{expr}, nil
+	return &PrintStmt{printToken, expr}, nil
 }
 
 func (p *Parser) exprStmt() (Stmt, error) {
+	// Empty statement
+	if p.match(l.SEMICOLON) != nil {
+		return nil, nil
+	}
+	firstToken := p.peek()
 	expr, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
-	if _, ok := expr.(*FuncExpr); !ok {
-		if err = p.consume(l.SEMICOLON, "Expected ';' after expression"); err != nil {
-			return nil, err
-		}
-	}
-	if expr == nil {
-		return nil, nil
+	if err = p.consume(l.SEMICOLON, "Expected ';' after expression"); err != nil {
+		return nil, err
 	}
-	return &ExprStmt{expr}, nil
+	return &ExprStmt{firstToken, expr}, nil
 }
 
 func (p *Parser) expression() (Expr, error) {
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer p.unnest()
+
 	return p.assignment()
 }
 
 func (p *Parser) assignment() (Expr, error) {
-	expr, err := p.binaryPrec(binRules, LOGICAL_OR)
+	expr, err := p.conditional()
 	if err != nil {
 		return nil, err
 	}
 
-	if equal := p.match(l.EQUAL); equal != nil {
-		expr, ok := expr.(*VariableExpr)
-		if !ok {
-			return nil, NewParserError(equal, "Invalid assignment target.")
+	operator := p.match(l.EQUAL, l.PLUS_EQUAL, l.MINUS_EQUAL, l.STAR_EQUAL, l.SLASH_EQUAL, l.PERCENT_EQUAL)
+	if operator != nil {
+		if list, ok := expr.(*ListExpr); ok && operator.Type == l.EQUAL {
+			return p.destructuringAssignment(list)
+		}
+		if !isAssignable(expr) {
+			return nil, NewParserError(operator, "Invalid assignment target.")
 		}
+		p.checkConstant(expr, operator)
 		assignment, err := p.assignment()
 		if err != nil {
 			return nil, err
 		}
-		return &AssignExpr{expr.Name, assignment}, nil
+		if variable, ok := expr.(*VariableExpr); ok {
+			return &AssignExpr{variable.Name, operator, assignment}, nil
+		}
+		return &SetExpr{expr, operator, assignment}, nil
 	}
 
 	return expr, nil
 }
 
+// conditional parses `condition ? then : else`, which is right
+// associative: a ? b : c ? d : e is a ? b : (c ? d : e)
+func (p *Parser) conditional() (Expr, error) {
+	condition, err := p.binaryPrec(binRules, COALESCE)
+	if err != nil {
+		return nil, err
+	}
+	question := p.match(l.QUESTION)
+	if question == nil {
+		return condition, nil
+	}
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer p.unnest()
+
+	then, err := p.assignment()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.consume(l.COLON, "Expect ':' after then branch of conditional expression."); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	return &ConditionalExpr{question, condition, then, otherwise}, nil
+}
+
 // `rules` parameter is an array of BinaryRule that were defined
 // with the priority go from highest to lowest accoding to its index.
 // `binaryPrec` should be passed zero value for `ruleIndex` parameter
@@ -285,6 +874,9 @@ func (p *Parser) binaryPrec(rules []BinaryRule, ruleIndex int) (Expr, error) {
 	if ruleIndex == len(rules) {
 		return p.unary()
 	}
+	if ruleIndex == RANGE {
+		return p.rangeExpr(rules)
+	}
 	expr, err := p.binaryPrec(rules, ruleIndex+1)
 	if err != nil {
 		return nil, err
@@ -307,46 +899,187 @@ func (p *Parser) binaryPrec(rules []BinaryRule, ruleIndex int) (Expr, error) {
 	}
 }
 
+// rangeExpr parses `start..end step n`, ranges don't chain
+func (p *Parser) rangeExpr(rules []BinaryRule) (Expr, error) {
+	start, err := p.binaryPrec(rules, RANGE+1)
+	if err != nil {
+		return nil, err
+	}
+	operator := p.match(rules[RANGE]...)
+	if operator == nil {
+		return start, nil
+	}
+	end, err := p.binaryPrec(rules, RANGE+1)
+	if err != nil {
+		return nil, err
+	}
+	var step Expr
+	// `step` is only a keyword after a range
+	if p.peek().Type == l.IDENTIFIER && p.peek().Value == "step" {
+		p.advance()
+		if step, err = p.binaryPrec(rules, RANGE+1); err != nil {
+			return nil, err
+		}
+	}
+	return &RangeExpr{operator, start, end, step}, nil
+}
+
 func (p *Parser) unary() (Expr, error) {
-	operator := p.match(l.BANG, l.MINUS)
+	operator := p.match(l.BANG, l.MINUS, l.TILDE, l.PLUS_PLUS, l.MINUS_MINUS)
 	if operator == nil {
-		return p.call()
+		return p.exponent()
 	}
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer p.unnest()
+
 	unaryExpr, err := p.unary()
 	if err != nil {
 		return nil, err
 	}
+	if operator.Type == l.PLUS_PLUS || operator.Type == l.MINUS_MINUS {
+		if !isAssignable(unaryExpr) {
+			return nil, NewParserError(operator, "Invalid increment target.")
+		}
+		p.checkConstant(unaryExpr, operator)
+		return &IncrementExpr{operator, unaryExpr, true}, nil
+	}
 	return &UnaryExpr{operator, unaryExpr}, nil
 }
+
+// exponent parses the postfix increments and `**`, which binds tighter
+// than a unary operator on its left and is right associative:
+// -2 ** 2 is -(2 ** 2) and 2 ** 3 ** 2 is 2 ** (3 ** 2)
+func (p *Parser) exponent() (Expr, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+	if operator := p.match(l.PLUS_PLUS, l.MINUS_MINUS); operator != nil {
+		if !isAssignable(expr) {
+			return nil, NewParserError(operator, "Invalid increment target.")
+		}
+		p.checkConstant(expr, operator)
+		expr = &IncrementExpr{operator, expr, false}
+	}
+	operator := p.match(l.STAR_STAR)
+	if operator == nil {
+		return expr, nil
+	}
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &BinaryExpr{operator, expr, exponent}, nil
+}
+
+// isAssignable reports whether an expression can be the target of an
+// assignment: a variable, an index or a property
+func isAssignable(expr Expr) bool {
+	switch expr.(type) {
+	case *VariableExpr, *IndexExpr, *GetExpr:
+		return true
+	default:
+		return false
+	}
+}
 func (p *Parser) call() (Expr, error) {
 	expr, err := p.primary()
 	if err != nil {
 		return nil, err
 	}
 
+	optional := false
 	for {
-		if p.match(l.LEFT_PAREN) != nil {
+		if p.match(l.QUESTION_DOT) != nil {
+			if err := p.consume(l.IDENTIFIER, "Expect property name after '?.'."); err != nil {
+				return nil, err
+			}
+			expr = &GetExpr{expr, p.previous(), true}
+			optional = true
+		} else if p.match(l.LEFT_PAREN) != nil {
 			expr, err = p.finishCall(expr)
 			if err != nil {
 				return nil, err
 			}
+		} else if p.match(l.LEFT_BRACKET) != nil {
+			expr, err = p.finishIndex(expr)
+			if err != nil {
+				return nil, err
+			}
+		} else if p.match(l.DOT) != nil {
+			if err := p.consume(l.IDENTIFIER, "Expect property name after '.'."); err != nil {
+				return nil, err
+			}
+			expr = &GetExpr{expr, p.previous(), false}
 		} else {
 			break
 		}
 	}
 
+	if optional {
+		return &OptionalChainExpr{expr}, nil
+	}
 	return expr, nil
 }
 
+func (p *Parser) finishIndex(object Expr) (Expr, error) {
+	bracket := p.previous()
+	var start, end Expr
+	var err error
+	if p.peek().Type != l.COLON {
+		start, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.match(l.COLON) == nil {
+		if err := p.consume(l.RIGHT_BRACKET, "Expect ']' after index."); err != nil {
+			return nil, err
+		}
+		return &IndexExpr{object, bracket, start}, nil
+	}
+	if p.peek().Type != l.RIGHT_BRACKET {
+		end, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := p.consume(l.RIGHT_BRACKET, "Expect ']' after slice."); err != nil {
+		return nil, err
+	}
+	return &SliceExpr{object, bracket, start, end}, nil
+}
+
 func (p *Parser) finishCall(callee Expr) (Expr, error) {
 	arguments := make([]Expr, 0)
+	namedArguments := make([]*NamedArgument, 0)
+	names := make(map[string]bool)
 	if p.peek().Type != l.RIGHT_PAREN {
 		for {
-			argument, err := p.expression()
-			if err != nil {
-				return nil, err
+			if p.peek().Type == l.IDENTIFIER && p.peekNext().Type == l.COLON {
+				name := p.advance()
+				p.advance()
+				if names[name.Value.(string)] {
+					return nil, NewParserError(name, "Duplicate argument '"+name.Value.(string)+"'.")
+				}
+				names[name.Value.(string)] = true
+				value, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				namedArguments = append(namedArguments, &NamedArgument{name, value})
+			} else {
+				if len(namedArguments) != 0 {
+					return nil, NewParserError(p.peek(), "Positional arguments can't follow named arguments.")
+				}
+				argument, err := p.spreadable()
+				if err != nil {
+					return nil, err
+				}
+				arguments = append(arguments, argument)
 			}
-			arguments = append(arguments, argument)
 			if p.match(l.COMMA) == nil {
 				break
 			}
@@ -359,17 +1092,24 @@ func (p *Parser) finishCall(callee Expr) (Expr, error) {
 	}
 
 	return &CallExpr{
-		Callee:    callee,
-		Arguments: arguments,
+		Callee:         callee,
+		Paren:          p.previous(),
+		Arguments:      arguments,
+		NamedArguments: namedArguments,
 	}, nil
 }
 
 func (p *Parser) primary() (Expr, error) {
-	token := p.advance()
+	token := p.peek()
 	switch token.Type {
-	case l.NUMBER, l.STRING, l.TRUE, l.FALSE, l.NIL:
-		return &PrimaryExpr{token}, nil
+	case l.NUMBER, l.DECIMAL, l.STRING, l.TRUE, l.FALSE, l.NIL:
+		return &PrimaryExpr{p.advance()}, nil
 	case l.LEFT_PAREN:
+		if p.isArrowFunction() {
+			p.advance()
+			return p.arrowFunction(true)
+		}
+		p.advance()
 		expr, err := p.expression()
 		if err != nil {
 			return nil, err
@@ -379,31 +1119,213 @@ func (p *Parser) primary() (Expr, error) {
 		}
 		return expr, nil
 	case l.IDENTIFIER:
-		return &VariableExpr{token}, nil
+		if p.peekNext().Type == l.ARROW {
+			return p.arrowFunction(false)
+		}
+		return &VariableExpr{p.advance()}, nil
+	case l.LEFT_BRACKET:
+		p.advance()
+		return p.list()
+	case l.LEFT_BRACE:
+		p.advance()
+		return p.mapLiteral()
+	case l.INTERPOLATION:
+		return p.interpolation()
 	case l.FUN:
-		return p.function()
+		p.advance()
+		// The function is still parsed, so its body doesn't report errors
+		if p.peek().Type == l.IDENTIFIER {
+			p.errors = append(p.errors, NewParserError(p.advance(), "Function expressions can't be named, declare the function instead."))
+		}
+		if err := p.consume(l.LEFT_PAREN, "Expect '(' after 'fun'."); err != nil {
+			return nil, err
+		}
+		funcStmt, err := p.function(nil)
+		if err != nil {
+			return nil, err
+		}
+		return &FuncExpr{funcStmt.(*FuncStmt)}, nil
 	default:
-		// Give back the token if don't match any precedence
-		p.current--
-		return nil, nil
+		return nil, NewParserError(token, "Expected expression.")
 	}
 }
 
-func (p *Parser) function() (Expr, error) {
-	funcName := p.match(l.IDENTIFIER)
+func (p *Parser) interpolation() (Expr, error) {
+	token := p.peek()
+	parts := make([]Expr, 0)
+	addSegment := func(segment *l.Token) {
+		if segment.Value != "" {
+			parts = append(parts, &PrimaryExpr{&l.Token{Type: l.STRING, Value: segment.Value, Line: segment.Line}})
+		}
+	}
+	for p.match(l.INTERPOLATION) != nil {
+		addSegment(p.previous())
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, expr)
+	}
+	if err := p.consume(l.STRING, "Expect '}' after interpolated expression."); err != nil {
+		return nil, err
+	}
+	addSegment(p.previous())
+	return &InterpolationExpr{token, parts}, nil
+}
+
+// spreadable parses an expression which may be spread, as an argument or
+// an element of a collection literal
+func (p *Parser) spreadable() (Expr, error) {
+	ellipsis := p.match(l.ELLIPSIS)
+	expr, err := p.expression()
+	if err != nil || ellipsis == nil {
+		return expr, err
+	}
+	return &SpreadExpr{ellipsis, expr}, nil
+}
+
+func (p *Parser) list() (Expr, error) {
+	bracket := p.previous()
+	elements := make([]Expr, 0)
+	for p.peek().Type != l.RIGHT_BRACKET {
+		element, err := p.spreadable()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+		if p.match(l.COMMA) == nil {
+			break
+		}
+	}
+	if err := p.consume(l.RIGHT_BRACKET, "Expect ']' after list elements."); err != nil {
+		return nil, err
+	}
+	return &ListExpr{bracket, elements}, nil
+}
+
+// mapLiteral parses a `{key: value}` literal, an identifier key is the string of
+// its name and `...other` spreads the entries of another map
+func (p *Parser) mapLiteral() (Expr, error) {
+	brace := p.previous()
+	keys, values := make([]Expr, 0), make([]Expr, 0)
+	for p.peek().Type != l.RIGHT_BRACE {
+		if p.peek().Type == l.ELLIPSIS {
+			spread, err := p.spreadable()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, spread)
+			values = append(values, nil)
+			if p.match(l.COMMA) == nil {
+				break
+			}
+			continue
+		}
+		var key Expr
+		if p.peek().Type == l.IDENTIFIER && p.peekNext().Type == l.COLON {
+			name := p.advance()
+			key = &PrimaryExpr{&l.Token{Type: l.STRING, Value: name.Value, Line: name.Line}}
+		} else {
+			var err error
+			if key, err = p.expression(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.consume(l.COLON, "Expect ':' after key."); err != nil {
+			return nil, err
+		}
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+		if p.match(l.COMMA) == nil {
+			break
+		}
+	}
+	if err := p.consume(l.RIGHT_BRACE, "Expect '}' after map entries."); err != nil {
+		return nil, err
+	}
+	return &MapExpr{brace, keys, values}, nil
+}
 
-	err := p.consume(l.LEFT_PAREN, "Expect '(' after function name.")
+// function parses the parameters and the body of a function whose opening
+// parenthesis was consumed, `name` is nil for anonymous functions
+func (p *Parser) function(name *l.Token) (Stmt, error) {
+	p.beginScope()
+	defer p.endScope()
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+	err = p.consume(l.LEFT_BRACE, "Expect '{' after function declaration.")
 	if err != nil {
 		return nil, err
 	}
-	parameters := make([]*l.Token, 0)
+	body, isGenerator, err := p.functionBody()
+	if err != nil {
+		return nil, err
+	}
+	return &FuncStmt{
+		Name:        name,
+		Parameters:  parameters,
+		Body:        body,
+		IsGenerator: isGenerator,
+	}, nil
+}
+
+// functionBody parses the block of a function and reports whether it
+// holds a yield statement
+func (p *Parser) functionBody() (*BlockStmt, bool, error) {
+	p.generators = append(p.generators, false)
+	defer func() {
+		p.generators = p.generators[:len(p.generators)-1]
+	}()
+	body, err := p.blockStmt()
+	if err != nil {
+		return nil, false, err
+	}
+	return body.(*BlockStmt), p.generators[len(p.generators)-1], nil
+}
+
+// parameters parses a parameter list up to its closing parenthesis and
+// declares the parameters in the current scope
+func (p *Parser) parameters() ([]*Parameter, error) {
+	parameters := make([]*Parameter, 0)
+	names := make(map[string]bool)
 	if p.peek().Type != l.RIGHT_PAREN {
 		for {
+			rest := p.match(l.ELLIPSIS) != nil
 			err := p.consume(l.IDENTIFIER, "Expect parameter name.")
 			if err != nil {
 				return nil, err
 			}
-			parameters = append(parameters, p.previous())
+			parameter := &Parameter{Name: p.previous(), Rest: rest}
+			if names[parameter.Name.Value.(string)] {
+				return nil, NewParserError(parameter.Name, "Duplicate parameter '"+parameter.Name.Value.(string)+"'.")
+			}
+			names[parameter.Name.Value.(string)] = true
+
+			if equal := p.match(l.EQUAL); equal != nil {
+				if rest {
+					return nil, NewParserError(equal, "Rest parameter can't have a default value.")
+				}
+				if parameter.Default, err = p.expression(); err != nil {
+					return nil, err
+				}
+			}
+			if len(parameters) != 0 {
+				last := parameters[len(parameters)-1]
+				if last.Rest {
+					return nil, NewParserError(parameter.Name, "Rest parameter must be the last parameter.")
+				}
+				if last.Default != nil && parameter.Default == nil && !rest {
+					return nil, NewParserError(parameter.Name, "Parameter without default value can't follow parameters with default values.")
+				}
+			}
+			parameters = append(parameters, parameter)
+			p.declare(parameter.Name, l.VAR)
 
 			if p.match(l.COMMA) != nil {
 				continue
@@ -412,37 +1334,85 @@ func (p *Parser) function() (Expr, error) {
 			}
 		}
 	}
-	err = p.consume(l.RIGHT_PAREN, "Expect ')' after parameters.")
-	if err != nil {
+	if err := p.consume(l.RIGHT_PAREN, "Expect ')' after parameters."); err != nil {
 		return nil, err
 	}
-	err = p.consume(l.LEFT_BRACE, "Expect '{' after function declaration.")
-	if err != nil {
-		return nil, err
+	return parameters, nil
+}
+
+// arrowFunction parses `(a, b) => expression`, `a => expression` or an
+// arrow function with a block body. The opening parenthesis of a
+// parenthesized parameter list was consumed.
+func (p *Parser) arrowFunction(parenthesized bool) (Expr, error) {
+	p.beginScope()
+	defer p.endScope()
+	var parameters []*Parameter
+	var err error
+	if parenthesized {
+		parameters, err = p.parameters()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		parameters = []*Parameter{{Name: p.advance()}}
+		p.declare(parameters[0].Name, l.VAR)
 	}
-	body, err := p.blockStmt()
-	if err != nil {
+	arrow := p.peek()
+	if err := p.consume(l.ARROW, "Expect '=>' after parameters."); err != nil {
 		return nil, err
 	}
-	return &FuncExpr{
-		FuncStmt: &FuncStmt{
-			Name:       funcName,
-			Parameters: parameters,
-			Body:       body.(*BlockStmt),
-		},
-	}, nil
+
+	var body *BlockStmt
+	isGenerator := false
+	if p.match(l.LEFT_BRACE) != nil {
+		body, isGenerator, err = p.functionBody()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		body = &BlockStmt{[]Stmt{&ReturnStmt{arrow, expr}}}
+	}
+	return &FuncExpr{&FuncStmt{
+		Parameters:  parameters,
+		Body:        body,
+		IsGenerator: isGenerator,
+	}}, nil
+}
+
+// isArrowFunction looks past the parenthesis at the current token for the
+// '=>' of an arrow function
+func (p *Parser) isArrowFunction() bool {
+	depth := 0
+	for j := p.current; j < len(p.tokens); j++ {
+		switch p.tokens[j].Type {
+		case l.LEFT_PAREN:
+			depth++
+		case l.RIGHT_PAREN:
+			depth--
+			if depth == 0 {
+				return j+1 < len(p.tokens) && p.tokens[j+1].Type == l.ARROW
+			}
+		case l.EOF:
+			return false
+		}
+	}
+	return false
 }
 
 func (p *Parser) isAtEnd() bool {
 	return p.tokens[p.current].Type == l.EOF
 }
 
+// advance never moves past the EOF token, which is returned instead
 func (p *Parser) advance() *l.Token {
-	if p.isAtEnd() {
-		return nil
-	}
 	token := p.tokens[p.current]
-	p.current++
+	if !p.isAtEnd() {
+		p.current++
+	}
 	return &token
 }
 
@@ -451,10 +1421,14 @@ func (p *Parser) previous() *l.Token {
 }
 
 func (p *Parser) peek() *l.Token {
+	return &p.tokens[p.current]
+}
+
+func (p *Parser) peekNext() *l.Token {
 	if p.isAtEnd() {
-		return nil
+		return p.peek()
 	}
-	return &p.tokens[p.current]
+	return &p.tokens[p.current+1]
 }
 
 func (p *Parser) match(types ...l.TokenType) *l.Token {
@@ -469,8 +1443,10 @@ func (p *Parser) match(types ...l.TokenType) *l.Token {
 	return nil
 }
 
+// synchronize discards tokens until the start of the next statement, it
+// always consumes the token that caused the error
 func (p *Parser) synchronize() {
-	previous := p.peek()
+	previous := p.advance()
 
 	for !p.isAtEnd() {
 		if previous.Type == l.SEMICOLON {
@@ -478,7 +1454,7 @@ func (p *Parser) synchronize() {
 		}
 
 		switch p.peek().Type {
-		case l.CLASS, l.FUN, l.VAR, l.FOR, l.IF, l.WHILE, l.PRINT, l.RETURN:
+		case l.CLASS, l.FUN, l.VAR, l.LET, l.CONST, l.FOR, l.IF, l.WHILE, l.PRINT, l.RETURN, l.MATCH, l.SPAWN, l.SELECT:
 			return
 		}
 
@@ -486,6 +1462,18 @@ func (p *Parser) synchronize() {
 	}
 }
 
+func (p *Parser) nest() error {
+	if p.depth == maxNesting {
+		return NewParserError(p.peek(), "Too much nesting.")
+	}
+	p.depth++
+	return nil
+}
+
+func (p *Parser) unnest() {
+	p.depth--
+}
+
 func (p *Parser) consume(tokenType l.TokenType, message string) error {
 	if p.tokens[p.current].Type != tokenType {
 		return NewParserError(&p.tokens[p.current], message)
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"testing"
+
+	l "github.com/debugg-er/lox/src/lexer"
+)
+
+func FuzzParser(f *testing.F) {
+	for _, seed := range []string{
+		"var a = 1; print a;",
+		"fun f(a, b) { return a + b; } print f(1, 2);",
+		"for (var i = 0; i < 3; i = i + 1) { if (i == 1) continue; }",
+		"print",
+		"var",
+		"class",
+		"f(",
+		"fun (",
+		"1 + ;",
+		"return;",
+		"{ while (true) {} break; }",
+		"test \"name\" { assert(true); }",
+		"a[0] += 1; b.c++; --d; print 2 ** -3 % 4;",
+		"print a ? b ?? c : d?.e.f;",
+		"const a = 1; let b; { let a = 2; a = 3; } a++; let b = 4;",
+		"const c; match (x) { case [c, d] => c = 1; }",
+		"var [a, [b, ...c]] = f(); var {d, e: [g]} = h(); [a, b] = [b, a]; return 1, 2;",
+		"f(...a, b); [...c, 1]; {...d, e: 1}; [x, ...y] = z; [...x, y] = z;",
+		"a: for (x in y) { b: while (true) { break a; continue b; break c; } } d: print 1;",
+		"for (;;) break; do { } while (x); loop { break; } l: loop { continue l; } do { } x;",
+		"a: b: while (x) break a; c: { }",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, source string) {
+		tokens, err := l.NewLexer().Parse(source)
+		if err != nil {
+			return
+		}
+		statements, errs := NewParser().Parse(tokens)
+		for _, err := range errs {
+			if err == nil {
+				t.Fatalf("nil error reported for %q", source)
+			}
+		}
+		for _, stmt := range statements {
+			if stmt == nil {
+				t.Fatalf("nil statement parsed from %q", source)
+			}
+		}
+	})
+}
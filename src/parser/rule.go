@@ -7,19 +7,31 @@ import (
 type BinaryRule []l.TokenType
 
 const (
-	LOGICAL_OR int = iota
+	COALESCE int = iota
+	LOGICAL_OR
 	LOGICAL_AND
 	EQUALITY
 	COMPARISON
+	RANGE
+	BIT_OR
+	BIT_XOR
+	BIT_AND
+	SHIFT
 	TERM
 	FACTOR
 )
 
 var binRules []BinaryRule = []BinaryRule{
+	[]l.TokenType{l.QUESTION_QUESTION},         // coalesce
 	[]l.TokenType{l.OR},                        // logical_or
 	[]l.TokenType{l.AND},                       // logical_and
 	[]l.TokenType{l.EQUAL_EQUAL, l.BANG_EQUAL}, // equality
 	[]l.TokenType{l.GREATER, l.GREATER_EQUAL, l.LESS, l.LESS_EQUAL}, // comparison
+	[]l.TokenType{l.DOT_DOT, l.DOT_DOT_EQUAL},                       // range
+	[]l.TokenType{l.PIPE},                                           // bit_or
+	[]l.TokenType{l.CARET},                                          // bit_xor
+	[]l.TokenType{l.AMPERSAND},                                      // bit_and
+	[]l.TokenType{l.LESS_LESS, l.GREATER_GREATER},                   // shift
 	[]l.TokenType{l.PLUS, l.MINUS},                                  // term
-	[]l.TokenType{l.STAR, l.SLASH},                                  // factor
+	[]l.TokenType{l.STAR, l.SLASH, l.PERCENT},                       // factor
 }
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"fmt"
+
+	l "github.com/debugg-er/lox/src/lexer"
+)
+
+// scope holds the variables declared in a block while it's parsed, so
+// assignments to constants and redeclarations are reported before the
+// program runs. Scopes follow the environments of the interpreter: blocks,
+// parameter lists, the initialization of a for loop and the bindings of a
+// for in loop, a match case or a select case.
+type scope struct {
+	variables map[string]*variable
+	enclosing *scope
+}
+
+// variable is a declared name along with the keyword declaring it: VAR,
+// LET, CONST or FUN. Parameters and bindings are VAR.
+type variable struct {
+	name *l.Token
+	kind l.TokenType
+}
+
+func newScope(enclosing *scope) *scope {
+	return &scope{make(map[string]*variable), enclosing}
+}
+
+func (p *Parser) beginScope() {
+	p.scope = newScope(p.scope)
+}
+
+func (p *Parser) endScope() {
+	p.scope = p.scope.enclosing
+}
+
+// declare adds a variable to the current scope. A `let` or a `const` can't
+// share its scope with another variable of the same name, while a `var` may
+// be declared again. A redeclaration isn't a syntax error, it's reported
+// without stopping the parsing.
+func (p *Parser) declare(name *l.Token, kind l.TokenType) {
+	identifier := name.Value.(string)
+	previous := p.scope.variables[identifier]
+	if previous != nil && (isLexical(kind) || isLexical(previous.kind)) {
+		p.errors = append(p.errors, NewParserError(name, fmt.Sprintf("Variable '%s' is already declared at line %d.", identifier, previous.name.Line)))
+		return
+	}
+	p.scope.variables[identifier] = &variable{name, kind}
+}
+
+func isLexical(kind l.TokenType) bool {
+	return kind == l.LET || kind == l.CONST
+}
+
+// checkConstant reports an assignment to `target` when it's a constant
+// declared in an enclosing scope. Constants declared after the assignment
+// is parsed, such as a global assigned by a function declared before it,
+// are reported by the interpreter instead.
+func (p *Parser) checkConstant(target Expr, token *l.Token) {
+	variable, ok := target.(*VariableExpr)
+	if !ok {
+		return
+	}
+	identifier := variable.Name.Value.(string)
+	for s := p.scope; s != nil; s = s.enclosing {
+		if declared := s.variables[identifier]; declared != nil {
+			if declared.kind == l.CONST {
+				p.errors = append(p.errors, NewParserError(token, fmt.Sprintf("Can't assign to constant '%s' declared at line %d.", identifier, declared.name.Line)))
+			}
+			return
+		}
+	}
+}
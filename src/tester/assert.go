@@ -0,0 +1,66 @@
+package tester
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/debugg-er/lox/src/interpreter"
+	l "github.com/debugg-er/lox/src/lexer"
+)
+
+func defineAssertions(i *interpreter.Interpreter) {
+	i.DefineNative(&interpreter.NativeFunction{Name: "assert", Arity: -1, Call: assert})
+	i.DefineNative(&interpreter.NativeFunction{Name: "assertEqual", Arity: 2, Call: assertEqual})
+	i.DefineNative(&interpreter.NativeFunction{Name: "assertThrows", Arity: -1, Call: assertThrows})
+}
+
+// assert(condition, message?)
+func assert(i *interpreter.Interpreter, token *l.Token, arguments []*interpreter.Value) (*interpreter.Value, error) {
+	if len(arguments) != 1 && len(arguments) != 2 {
+		return nil, interpreter.NewRuntimeError(token, "assert expects a condition and an optional message.")
+	}
+	if isTrue(*arguments[0]) {
+		return interpreter.NewValue(nil), nil
+	}
+	message := "AssertionError: expected true, got " + arguments[0].Repr()
+	if len(arguments) == 2 {
+		message = "AssertionError: " + arguments[1].Stringify()
+	}
+	return nil, interpreter.NewRuntimeError(token, message)
+}
+
+// assertEqual(expected, actual)
+func assertEqual(i *interpreter.Interpreter, token *l.Token, arguments []*interpreter.Value) (*interpreter.Value, error) {
+	expected, actual := arguments[0], arguments[1]
+	if expected.Equals(*actual) {
+		return interpreter.NewValue(nil), nil
+	}
+	message := fmt.Sprintf("AssertionError: expected %s, got %s", expected.Repr(), actual.Repr())
+	return nil, interpreter.NewRuntimeError(token, message)
+}
+
+// assertThrows(function, message?) calls `function` without arguments and
+// fails unless it raises an error, containing `message` when given.
+func assertThrows(i *interpreter.Interpreter, token *l.Token, arguments []*interpreter.Value) (*interpreter.Value, error) {
+	if len(arguments) != 1 && len(arguments) != 2 {
+		return nil, interpreter.NewRuntimeError(token, "assertThrows expects a function and an optional message.")
+	}
+	if arguments[0].DataType != interpreter.FUNCTION_DT {
+		return nil, interpreter.NewRuntimeError(token, "assertThrows expects a function, got "+arguments[0].Repr())
+	}
+	_, err := i.Call(arguments[0], []*interpreter.Value{}, token)
+	if err == nil {
+		return nil, interpreter.NewRuntimeError(token, "AssertionError: expected an error to be thrown")
+	}
+	if len(arguments) == 2 && !strings.Contains(err.Error(), arguments[1].Stringify()) {
+		message := fmt.Sprintf("AssertionError: expected error containing %s, got %s",
+			strconv.Quote(arguments[1].Stringify()), strconv.Quote(strings.TrimSpace(err.Error())))
+		return nil, interpreter.NewRuntimeError(token, message)
+	}
+	return interpreter.NewValue(nil), nil
+}
+
+func isTrue(value interpreter.Value) bool {
+	return value.DataType == interpreter.BOOLEAN_DT && value.Data.(bool)
+}
@@ -0,0 +1,176 @@
+package tester
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/debugg-er/lox/src/interpreter"
+	l "github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+const (
+	fileSuffix     = "_test.lox"
+	functionPrefix = "test_"
+)
+
+type Result struct {
+	File string
+	Name string
+	Err  error
+}
+
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+// Discover returns the test files found in `paths`, directories are walked
+// recursively for files ending with "_test.lox"
+func Discover(paths []string) ([]string, error) {
+	files := make([]string, 0)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), fileSuffix) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// RunFile runs every test of a file. Each test gets its own interpreter in
+// which the top level statements of the file are executed before the test
+// itself, so state never leaks from a test to another.
+func RunFile(path string) []Result {
+	results, _ := runFile(path, false)
+	return results
+}
+
+// runFile runs the tests of a file like RunFile. With `coverage`, the
+// interpreters of the tests record their hits in the returned coverage of
+// the file, which is nil when the file can't be parsed.
+func runFile(path string, coverage bool) ([]Result, *interpreter.Coverage) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return []Result{{File: path, Err: err}}, nil
+	}
+	tokens, err := l.NewLexer().Parse(string(source))
+	if err != nil {
+		return []Result{{File: path, Err: err}}, nil
+	}
+	statements, errs := parser.NewParser().Parse(tokens)
+	if len(errs) != 0 {
+		return []Result{{File: path, Err: errs[0]}}, nil
+	}
+
+	var fileCoverage *interpreter.Coverage
+	if coverage {
+		fileCoverage = interpreter.NewCoverage(statements)
+	}
+	results := make([]Result, 0)
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *parser.TestStmt:
+			err := runTest(statements, stmt.Body, fileCoverage)
+			results = append(results, Result{path, stmt.Name, err})
+		case *parser.FuncStmt:
+			name := stmt.Name
+			if !strings.HasPrefix(name.Value.(string), functionPrefix) {
+				continue
+			}
+			call := &parser.ExprStmt{
+				Token: name,
+				Expr: &parser.CallExpr{
+					Callee:    &parser.VariableExpr{Name: name},
+					Paren:     name,
+					Arguments: []parser.Expr{},
+				},
+			}
+			err := runTest(statements, call, fileCoverage)
+			results = append(results, Result{path, name.Value.(string), err})
+		}
+	}
+	return results, fileCoverage
+}
+
+func runTest(setup []parser.Stmt, test parser.Stmt, coverage *interpreter.Coverage) error {
+	i := interpreter.NewInterpreter()
+	i.SetCoverage(coverage)
+	defineAssertions(i)
+	if err := i.Run(setup); err != nil {
+		return err
+	}
+	return i.Run([]parser.Stmt{test})
+}
+
+// failure describes the error of a failed test, runtime errors are located
+// as file:line
+func failure(result Result) string {
+	if err, ok := result.Err.(*interpreter.Error); ok {
+		return fmt.Sprintf("%s:%d: %s", result.File, err.Line(), err.Message())
+	}
+	return strings.TrimSpace(result.Err.Error())
+}
+
+// Run runs the tests of every file and writes a report to `w`, it returns
+// false when any test failed. When `coverage` isn't nil, it receives an
+// LCOV record of each file merging the hits of all its tests, and the
+// summaries of the records follow the report.
+func Run(files []string, w io.Writer, coverage io.Writer) bool {
+	passed, failed := 0, 0
+	summaries := make([]string, 0)
+	var coverageErr error
+	for _, file := range files {
+		results, fileCoverage := runFile(file, coverage != nil)
+		if fileCoverage != nil {
+			if err := fileCoverage.WriteLCOV(coverage, file); err != nil && coverageErr == nil {
+				coverageErr = err
+			}
+			summaries = append(summaries, fileCoverage.Summary(file))
+		}
+		for _, result := range results {
+			if result.Passed() {
+				passed++
+				fmt.Fprintf(w, "PASS  %s > %s\n", result.File, result.Name)
+				continue
+			}
+			failed++
+			if result.Name == "" {
+				fmt.Fprintf(w, "FAIL  %s\n", result.File)
+			} else {
+				fmt.Fprintf(w, "FAIL  %s > %s\n", result.File, result.Name)
+			}
+			fmt.Fprintf(w, "      %s\n", failure(result))
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d failed, %d total\n", passed, failed, passed+failed)
+	for _, summary := range summaries {
+		fmt.Fprintf(w, "\n%s", summary)
+	}
+	if coverageErr != nil {
+		fmt.Fprintf(w, "\n%s\n", coverageErr.Error())
+		return false
+	}
+	return failed == 0
+}
@@ -0,0 +1,166 @@
+package tester
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	files, err := Discover([]string{"testdata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		filepath.Join("testdata", "math_test.lox"),
+		filepath.Join("testdata", "nested", "assertions_test.lox"),
+		filepath.Join("testdata", "nested", "passing_test.lox"),
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+
+	// A file given explicitly is run whatever its name
+	files, err = Discover([]string{filepath.Join("testdata", "helper.lox")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected the file itself, got %v", files)
+	}
+
+	if _, err := Discover([]string{"missing"}); err == nil {
+		t.Error("expected an error for a missing path")
+	}
+}
+
+// Test blocks and test_ functions run in declaration order, a failing test
+// doesn't stop the following ones
+func TestRunFile(t *testing.T) {
+	path := filepath.Join("testdata", "math_test.lox")
+	results := RunFile(path)
+	expected := []struct {
+		name   string
+		passed bool
+	}{
+		{"square of an integer", true},
+		{"wrong expectation", false},
+		{"runtime error", false},
+		{"test_square_of_zero", true},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %v", len(expected), results)
+	}
+	for j, result := range results {
+		if result.File != path || result.Name != expected[j].name || result.Passed() != expected[j].passed {
+			t.Errorf("expected %s passed=%t, got %s passed=%t (%v)",
+				expected[j].name, expected[j].passed, result.Name, result.Passed(), result.Err)
+		}
+	}
+}
+
+func TestRunFileMissing(t *testing.T) {
+	results := RunFile(filepath.Join("testdata", "missing_test.lox"))
+	if len(results) != 1 || results[0].Passed() || results[0].Name != "" {
+		t.Errorf("expected a failed result for the file, got %v", results)
+	}
+}
+
+func TestRun(t *testing.T) {
+	files, err := Discover([]string{"testdata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if Run(files, &out, nil) {
+		t.Error("expected Run to report failures")
+	}
+	math := filepath.Join("testdata", "math_test.lox")
+	assertions := filepath.Join("testdata", "nested", "assertions_test.lox")
+	passing := filepath.Join("testdata", "nested", "passing_test.lox")
+	expected := strings.Join([]string{
+		"PASS  " + math + " > square of an integer",
+		"FAIL  " + math + " > wrong expectation",
+		"      " + math + ":10: AssertionError: expected 10, got 9",
+		"FAIL  " + math + " > runtime error",
+		"      " + math + ":2: Operands must be a number",
+		"PASS  " + math + " > test_square_of_zero",
+		"PASS  " + assertions + " > assert passes",
+		"FAIL  " + assertions + " > assert without message",
+		"      " + assertions + ":9: AssertionError: expected true, got null",
+		"FAIL  " + assertions + " > assert with message",
+		"      " + assertions + ":13: AssertionError: one isn't greater than two",
+		"PASS  " + assertions + " > state doesn't leak between tests",
+		"PASS  " + assertions + " > test_fresh_state",
+		"PASS  " + assertions + " > assertThrows passes",
+		"FAIL  " + assertions + " > assertThrows without error",
+		"      " + assertions + ":30: AssertionError: expected an error to be thrown",
+		"FAIL  " + assertions + " > assertThrows with another error",
+		"      " + assertions + ":34: AssertionError: expected error containing \"Division by zero\", got \"Line 34 at 'identifier': Undefined variable 'nope'.\"",
+		"PASS  " + passing + " > passes",
+		"",
+		"7 passed, 6 failed, 13 total",
+	}, "\n") + "\n"
+	if out.String() != expected {
+		t.Errorf("expected report\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestRunPassing(t *testing.T) {
+	var out strings.Builder
+	if !Run([]string{filepath.Join("testdata", "nested", "passing_test.lox")}, &out, nil) {
+		t.Errorf("expected Run to succeed\n%s", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "\n1 passed, 0 failed, 1 total\n") {
+		t.Errorf("unexpected summary\n%s", out.String())
+	}
+}
+
+// The coverage of a file merges the hits of all its tests, each file gets
+// its own record and summary
+func TestRunCoverage(t *testing.T) {
+	path := filepath.Join("testdata", "coverage.lox")
+	passing := filepath.Join("testdata", "nested", "passing_test.lox")
+	var out, lcov strings.Builder
+	if !Run([]string{path, passing}, &out, &lcov) {
+		t.Errorf("expected Run to succeed\n%s", out.String())
+	}
+	// Only the negative test takes the branch, the top level statements
+	// run before each of the three tests
+	expected := strings.Join([]string{
+		"TN:",
+		"SF:" + path,
+		"BRDA:2,0,0,1",
+		"BRDA:2,0,1,2",
+		"BRF:2",
+		"BRH:2",
+		"DA:1,3",
+		"DA:2,3",
+		"DA:3,1",
+		"DA:5,2",
+		"DA:16,3",
+		"DA:17,1",
+		"LF:6",
+		"LH:6",
+		"end_of_record",
+		"TN:",
+		"SF:" + passing,
+		"BRF:0",
+		"BRH:0",
+		"LF:0",
+		"LH:0",
+		"end_of_record",
+	}, "\n") + "\n"
+	if lcov.String() != expected {
+		t.Errorf("expected report\n%s\ngot\n%s", expected, lcov.String())
+	}
+	summary := "\n4 passed, 0 failed, 4 total\n" +
+		"\nCoverage of " + path + "\n" +
+		"  Lines:    6/6 (100.0%)\n" +
+		"  Branches: 2/2 (100.0%)\n" +
+		"\nCoverage of " + passing + "\n"
+	if !strings.Contains(out.String(), summary) {
+		t.Errorf("expected summaries\n%s\ngot\n%s", summary, out.String())
+	}
+}
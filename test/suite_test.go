@@ -0,0 +1,165 @@
+// Package test runs the conformance suite: every .lox file under this
+// directory is executed and its output compared against the annotations
+// written in its comments.
+//
+//	print 1 + 2; // expect: 3
+//	print -"a";  // expect runtime error: Bad datatype for unary operator
+//	print (1;    // error at line 3
+//
+// A runtime error is expected on the line of its annotation, while a
+// compile error annotation names its line explicitly and may be followed
+// by a part of the message, e.g. `// error at line 3: Expected ')'`.
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/debugg-er/lox/src/interpreter"
+	"github.com/debugg-er/lox/src/lexer"
+	"github.com/debugg-er/lox/src/optimizer"
+	"github.com/debugg-er/lox/src/parser"
+)
+
+var (
+	expectOutputPattern       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectRuntimeErrorPattern = regexp.MustCompile(`// expect runtime error: (.+)`)
+	expectErrorPattern        = regexp.MustCompile(`// error at line (\d+)(?:: (.+))?`)
+	errorLinePattern          = regexp.MustCompile(`[Ll]ine (\d+)`)
+)
+
+type expectedError struct {
+	line    int
+	message string
+}
+
+type expectations struct {
+	output       []string
+	runtimeError *expectedError
+	errors       []expectedError
+}
+
+func TestSuite(t *testing.T) {
+	err := filepath.WalkDir(".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".lox" {
+			return nil
+		}
+		t.Run(strings.TrimSuffix(path, ".lox"), func(t *testing.T) {
+			runFile(t, path)
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runFile(t *testing.T, path string) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := parseExpectations(t, string(source))
+	output, runtimeErr, errs := run(string(source))
+
+	if len(expected.errors) != 0 || len(errs) != 0 {
+		checkErrors(t, expected.errors, errs)
+		return
+	}
+
+	actual := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if output == "" {
+		actual = []string{}
+	}
+	for j := 0; j < len(expected.output) || j < len(actual); j++ {
+		switch {
+		case j >= len(actual):
+			t.Errorf("missing output %q", expected.output[j])
+		case j >= len(expected.output):
+			t.Errorf("unexpected output %q", actual[j])
+		case actual[j] != expected.output[j]:
+			t.Errorf("expected output %q, got %q", expected.output[j], actual[j])
+		}
+	}
+
+	switch {
+	case expected.runtimeError == nil && runtimeErr != nil:
+		t.Errorf("unexpected runtime error: %s", strings.TrimSpace(runtimeErr.Error()))
+	case expected.runtimeError != nil && runtimeErr == nil:
+		t.Errorf("expected runtime error %q at line %d", expected.runtimeError.message, expected.runtimeError.line)
+	case expected.runtimeError != nil:
+		checkError(t, *expected.runtimeError, runtimeErr)
+	}
+}
+
+func parseExpectations(t *testing.T, source string) expectations {
+	expected := expectations{output: make([]string, 0)}
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if match := expectRuntimeErrorPattern.FindStringSubmatch(text); match != nil {
+			expected.runtimeError = &expectedError{line, match[1]}
+		} else if match := expectOutputPattern.FindStringSubmatch(text); match != nil {
+			expected.output = append(expected.output, match[1])
+		} else if match := expectErrorPattern.FindStringSubmatch(text); match != nil {
+			errorLine, err := strconv.Atoi(match[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected.errors = append(expected.errors, expectedError{errorLine, match[2]})
+		}
+	}
+	return expected
+}
+
+// run executes a program the same way the command line does, it returns
+// the printed output and either a runtime error or the compile errors
+func run(source string) (string, error, []error) {
+	tokens, err := lexer.NewLexer().Parse(source)
+	if err != nil {
+		return "", nil, []error{err}
+	}
+	statements, errs := parser.NewParser().Parse(tokens)
+	if len(errs) != 0 {
+		return "", nil, errs
+	}
+
+	var output bytes.Buffer
+	i := interpreter.NewInterpreter()
+	i.SetOutput(&output)
+	err = i.Run(optimizer.Optimize(statements))
+	return output.String(), err, nil
+}
+
+func checkErrors(t *testing.T, expected []expectedError, actual []error) {
+	if len(expected) != len(actual) {
+		for _, err := range actual {
+			t.Log(strings.TrimSpace(err.Error()))
+		}
+		t.Fatalf("expected %d errors, got %d", len(expected), len(actual))
+	}
+	for j := range expected {
+		checkError(t, expected[j], actual[j])
+	}
+}
+
+func checkError(t *testing.T, expected expectedError, actual error) {
+	message := strings.TrimSpace(actual.Error())
+	match := errorLinePattern.FindStringSubmatch(message)
+	if match == nil || match[1] != strconv.Itoa(expected.line) {
+		t.Errorf("expected error at line %d, got %q", expected.line, message)
+	}
+	if !strings.Contains(message, expected.message) {
+		t.Errorf("expected error %q, got %q", expected.message, message)
+	}
+}